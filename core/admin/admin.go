@@ -0,0 +1,197 @@
+// Package admin 提供可选的 HTTP 运维管理模块，统一暴露 core 应用的运行状态与管理操作。
+//
+// 管理模块本身是一个普通的 core.IModule，与业务模块一起注册即可启用：
+//
+//	adm := admin.New("unix:///var/run/game/admin.sock",
+//		admin.WithFactory("battle", newBattleModule),
+//	)
+//	core.Run(adm, gate, world)
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wildmap/utility/core"
+	"github.com/wildmap/utility/core/chanrpc"
+	"github.com/wildmap/utility/xlog"
+	"github.com/wildmap/utility/xnet/listeners"
+)
+
+const (
+	// defaultName 管理模块的默认名称。
+	defaultName = "admin"
+	// shutdownTimeout 模块停止时等待进行中的 HTTP 请求处理完毕的最长时间。
+	shutdownTimeout = 5 * time.Second
+)
+
+// Factory 动态模块工厂函数，根据模块名称和请求参数创建待加载的动态模块实例。
+//
+// 通过 POST /modules/add 加载动态模块时，管理模块按请求中的 factory 字段查找对应工厂，
+// 工厂返回的模块 Name() 应与传入的 name 一致，以便后续通过名称卸载。
+type Factory func(name string, params map[string]string) (core.IModule, error)
+
+// Option 管理模块配置选项的函数类型，使用函数选项模式。
+type Option func(*Module)
+
+// WithName 设置管理模块的名称，默认为 "admin"，同一应用中注册多个管理模块时需区分名称。
+func WithName(name string) Option {
+	return func(m *Module) {
+		m.name = name
+	}
+}
+
+// WithApp 设置管理模块操作的应用实例，默认为 core.Default() 返回的全局默认实例。
+func WithApp(app *core.App) Option {
+	return func(m *Module) {
+		m.app = app
+	}
+}
+
+// WithFactory 注册动态模块工厂，kind 为 POST /modules/add 请求中引用的工厂名称。
+func WithFactory(kind string, f Factory) Option {
+	return func(m *Module) {
+		m.factories[kind] = f
+	}
+}
+
+// Module HTTP 运维管理模块，实现 core.IModule 接口。
+//
+// 提供的接口：
+//   - GET  /state：应用当前状态
+//   - GET  /stats：App.Stats 输出的模块队列统计
+//   - GET  /metrics：Prometheus 文本格式的模块指标
+//   - GET  /health：App.Health 健康报告，未就绪时返回 503
+//   - GET  /modules：动态模块名称列表及已注册的工厂名称
+//   - POST /modules/add：通过工厂创建并加载动态模块（全部成功或全部回滚）
+//   - POST /modules/remove：卸载指定名称的动态模块
+//   - GET  /loglevel、POST /loglevel：查询和调整全局日志级别
+//   - GET  /reload、POST /reload：查询最近一次重新加载结果、触发模块重新加载（同 SIGHUP）
+//   - GET  /messages：chanrpc 全局消息注册表（消息 ID → 类型），用于排查消息 ID 碰撞
+//   - /debug/pprof/：Go 运行时性能分析
+//
+// 管理模块不接受 ChanRPC 调用，其 HTTP 处理函数运行在 http.Server 的 goroutine 中，
+// 只通过 core.App 的并发安全方法访问应用状态。
+type Module struct {
+	name      string
+	addr      string
+	app       *core.App          // 管理的应用实例
+	mu        sync.RWMutex       // 保护 factories，支持运行期间通过 RegisterFactory 追加工厂
+	factories map[string]Factory // 工厂名称 → 动态模块工厂
+	ln        net.Listener
+	server    *http.Server
+}
+
+// New 创建 HTTP 运维管理模块。
+//
+// addr 格式与 xnet.Server 一致：
+//   - "127.0.0.1:6060" 或 "tcp://127.0.0.1:6060"：监听 TCP 端口
+//   - "unix:///var/run/app/admin.sock"：监听 Unix Domain Socket，权限 0660
+//
+// 管理接口可加载/卸载模块并调整日志级别，生产环境应仅监听本机地址或 Unix Socket。
+func New(addr string, opts ...Option) *Module {
+	m := &Module{
+		name:      defaultName,
+		addr:      addr,
+		app:       core.Default(),
+		factories: make(map[string]Factory),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// RegisterFactory 注册动态模块工厂，可在运行期间调用，同名工厂不允许重复注册。
+func (m *Module) RegisterFactory(kind string, f Factory) error {
+	if f == nil {
+		return errors.New("admin: factory cannot be nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.factories[kind]; ok {
+		return fmt.Errorf("admin: factory %s already registered", kind)
+	}
+	m.factories[kind] = f
+	return nil
+}
+
+// factory 查找指定名称的动态模块工厂。
+func (m *Module) factory(kind string) (Factory, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, ok := m.factories[kind]
+	return f, ok
+}
+
+// Name 返回模块名称，实现 core.IModule 接口。
+func (m *Module) Name() string {
+	return m.name
+}
+
+// OnInit 创建监听器，实现 core.IModule 接口。
+//
+// 在初始化阶段完成监听，端口占用等错误会使应用启动失败，而不是在运行期间静默丢失管理入口。
+func (m *Module) OnInit() error {
+	proto, host, found := strings.Cut(m.addr, "://")
+	if !found {
+		proto = "tcp"
+		host = m.addr
+	}
+
+	ln, err := listeners.New(context.Background(), proto, host, nil)
+	if err != nil {
+		return fmt.Errorf("admin listen %s failed: %w", m.addr, err)
+	}
+	m.ln = ln
+	m.server = &http.Server{
+		Handler:           m.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return nil
+}
+
+// OnRun 启动 HTTP 服务并阻塞至 ctx 被取消，随后优雅关闭服务，实现 core.IModule 接口。
+func (m *Module) OnRun(ctx context.Context) {
+	errCh := make(chan error, 1)
+	go func() {
+		xlog.Infof("admin server listening at %s", m.ln.Addr())
+		errCh <- m.server.Serve(m.ln)
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			xlog.Errorf("admin server stopped, err %v", err)
+		}
+		return
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := m.server.Shutdown(shutdownCtx); err != nil {
+		xlog.Warnf("admin server shutdown err %v", err)
+	}
+}
+
+// OnDestroy 关闭监听器，实现 core.IModule 接口。
+//
+// 正常流程下 OnRun 的 Shutdown 已关闭监听器，此处为 OnRun 未执行（如启动失败回滚）时兜底。
+func (m *Module) OnDestroy() {
+	if m.ln != nil {
+		_ = m.ln.Close()
+	}
+}
+
+// ChanRPC 返回 nil，管理模块不接受 ChanRPC 调用，实现 core.IModule 接口。
+func (m *Module) ChanRPC() *chanrpc.Server {
+	return nil
+}
@@ -0,0 +1,148 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wildmap/utility/core"
+	"github.com/wildmap/utility/core/chanrpc"
+	"github.com/wildmap/utility/xlog"
+)
+
+func TestMain(m *testing.M) {
+	// xlog 的懒初始化不是并发安全的，在 HTTP 处理函数和模块 goroutine 并发打印日志前先完成初始化
+	xlog.SetupLogger("")
+	os.Exit(m.Run())
+}
+
+// testModule 由工厂创建的测试模块，params["deps"] 为逗号分隔的依赖模块名称。
+type testModule struct {
+	name string
+	deps []string
+}
+
+func (m *testModule) Name() string              { return m.name }
+func (m *testModule) Dependencies() []string    { return m.deps }
+func (m *testModule) OnInit() error             { return nil }
+func (m *testModule) OnRun(ctx context.Context) { <-ctx.Done() }
+func (m *testModule) OnDestroy()                {}
+func (m *testModule) ChanRPC() *chanrpc.Server  { return nil }
+
+func newTestModule(name string, params map[string]string) (core.IModule, error) {
+	m := &testModule{name: name}
+	if deps := params["deps"]; deps != "" {
+		m.deps = strings.Split(deps, ",")
+	}
+	return m, nil
+}
+
+// do 向管理接口发送请求，返回状态码和响应体。
+func do(t *testing.T, srv *httptest.Server, method, path, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func newTestServer(t *testing.T) (*core.App, *httptest.Server) {
+	t.Helper()
+	app := core.NewApp()
+	m := New("", WithApp(app), WithFactory("test", newTestModule))
+	srv := httptest.NewServer(m.routes())
+	t.Cleanup(func() {
+		srv.Close()
+		for _, name := range []string{"c", "b", "a"} {
+			app.RemoveDynamicModule(name)
+		}
+	})
+	return app, srv
+}
+
+func TestStateAndMetrics(t *testing.T) {
+	_, srv := newTestServer(t)
+
+	code, body := do(t, srv, http.MethodGet, "/state", "")
+	var state struct {
+		State int32  `json:"state"`
+		Name  string `json:"name"`
+	}
+	if code != http.StatusOK || json.Unmarshal([]byte(body), &state) != nil || state.Name != "none" {
+		t.Errorf("GET /state = %d %s", code, body)
+	}
+
+	code, body = do(t, srv, http.MethodGet, "/metrics", "")
+	if code != http.StatusOK || !strings.Contains(body, "# TYPE core_threads_locked gauge") {
+		t.Errorf("GET /metrics = %d %s", code, body)
+	}
+}
+
+func TestAddRemoveModules(t *testing.T) {
+	app, srv := newTestServer(t)
+
+	if code, body := do(t, srv, http.MethodPost, "/modules/add", `{"factory":"test","names":["a","b"]}`); code != http.StatusOK {
+		t.Fatalf("add = %d %s", code, body)
+	}
+	if code, body := do(t, srv, http.MethodPost, "/modules/add", `{"factory":"test","names":["c"],"params":{"deps":"b"}}`); code != http.StatusOK {
+		t.Fatalf("add dependent = %d %s", code, body)
+	}
+	if code, _ := do(t, srv, http.MethodPost, "/modules/add", `{"factory":"missing","names":["d"]}`); code != http.StatusNotFound {
+		t.Errorf("add by unknown factory = %d, want 404", code)
+	}
+	if code, _ := do(t, srv, http.MethodPost, "/modules/add", `{"factory":"test","names":["a"]}`); code != http.StatusInternalServerError {
+		t.Errorf("add duplicate = %d, want 500", code)
+	}
+
+	code, body := do(t, srv, http.MethodGet, "/modules", "")
+	if code != http.StatusOK || !strings.Contains(body, `"dynamic":["a","b","c"]`) || !strings.Contains(body, `"factories":["test"]`) {
+		t.Errorf("GET /modules = %d %s", code, body)
+	}
+
+	for _, tt := range []struct {
+		name string
+		want int
+	}{
+		{"b", http.StatusConflict},
+		{"missing", http.StatusNotFound},
+		{"", http.StatusBadRequest},
+		{"c", http.StatusOK},
+		{"b", http.StatusOK},
+		{"b", http.StatusNotFound},
+	} {
+		if code, body := do(t, srv, http.MethodPost, "/modules/remove?name="+tt.name, ""); code != tt.want {
+			t.Errorf("remove %q = %d %s, want %d", tt.name, code, body, tt.want)
+		}
+	}
+	if names := app.DynamicModules(); len(names) != 1 || names[0] != "a" {
+		t.Errorf("dynamic modules = %v, want [a]", names)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	_, srv := newTestServer(t)
+	prev := xlog.GetLevel()
+	t.Cleanup(func() { xlog.SetLevel(prev) })
+
+	if code, body := do(t, srv, http.MethodPost, "/loglevel?level=warn", ""); code != http.StatusOK {
+		t.Fatalf("set level = %d %s", code, body)
+	}
+	if code, body := do(t, srv, http.MethodGet, "/loglevel", ""); code != http.StatusOK || !strings.Contains(body, `"level":"warn"`) {
+		t.Errorf("GET /loglevel = %d %s, want warn", code, body)
+	}
+	if code, _ := do(t, srv, http.MethodPost, "/loglevel?level=verbose", ""); code != http.StatusBadRequest {
+		t.Errorf("set invalid level = %d, want 400", code)
+	}
+}
@@ -0,0 +1,213 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"slices"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/wildmap/utility/core"
+	"github.com/wildmap/utility/core/chanrpc"
+	"github.com/wildmap/utility/xlog"
+)
+
+// stateNames 应用状态常量对应的可读名称，用于 /state 接口输出。
+var stateNames = map[int32]string{
+	core.AppStateNone: "none",
+	core.AppStateInit: "init",
+	core.AppStateRun:  "run",
+	core.AppStateStop: "stop",
+}
+
+// addRequest POST /modules/add 的请求体。
+type addRequest struct {
+	Factory string            `json:"factory"` // 工厂名称，对应 WithFactory/RegisterFactory 注册时的 kind
+	Names   []string          `json:"names"`   // 待创建的模块名称列表，同一批次全部成功或全部回滚
+	Params  map[string]string `json:"params"`  // 透传给工厂的参数
+}
+
+// routes 构建管理接口的路由表。
+func (m *Module) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /state", m.handleState)
+	mux.HandleFunc("GET /stats", m.handleStats)
+	mux.HandleFunc("GET /metrics", m.handleMetrics)
+	mux.HandleFunc("GET /health", m.handleHealth)
+	mux.HandleFunc("GET /modules", m.handleModules)
+	mux.HandleFunc("POST /modules/add", m.handleAddModules)
+	mux.HandleFunc("POST /modules/remove", m.handleRemoveModule)
+	mux.HandleFunc("GET /loglevel", m.handleGetLogLevel)
+	mux.HandleFunc("POST /loglevel", m.handleSetLogLevel)
+	mux.HandleFunc("GET /reload", m.handleReloadResults)
+	mux.HandleFunc("POST /reload", m.handleReload)
+	mux.HandleFunc("GET /messages", m.handleMessages)
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// handleState 返回应用当前状态。
+func (m *Module) handleState(w http.ResponseWriter, _ *http.Request) {
+	state := m.app.GetState()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"state": state,
+		"name":  stateNames[state],
+	})
+}
+
+// handleStats 以纯文本返回 App.Stats 的模块队列统计。
+func (m *Module) handleStats(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(m.app.Stats()))
+}
+
+// handleMetrics 以 Prometheus 文本暴露格式返回模块指标。
+func (m *Module) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := m.app.WritePrometheus(w); err != nil {
+		xlog.Warnf("admin write metrics err %v", err)
+	}
+}
+
+// handleHealth 返回健康报告，应用未就绪时使用 503 状态码，便于直接作为就绪探针。
+func (m *Module) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	report := m.app.Health()
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// handleModules 返回动态模块名称列表及可用的工厂名称。
+func (m *Module) handleModules(w http.ResponseWriter, _ *http.Request) {
+	m.mu.RLock()
+	factories := make([]string, 0, len(m.factories))
+	for kind := range m.factories {
+		factories = append(factories, kind)
+	}
+	m.mu.RUnlock()
+	slices.Sort(factories)
+
+	dynamic := m.app.DynamicModules()
+	slices.Sort(dynamic)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"dynamic":   dynamic,
+		"factories": factories,
+	})
+}
+
+// handleAddModules 通过工厂创建一批动态模块并以全部成功或全部回滚的语义加载。
+func (m *Module) handleAddModules(w http.ResponseWriter, r *http.Request) {
+	var req addRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode request failed: %w", err))
+		return
+	}
+	if len(req.Names) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("module names cannot be empty"))
+		return
+	}
+
+	factory, ok := m.factory(req.Factory)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("factory %s not registered", req.Factory))
+		return
+	}
+
+	mods := make([]core.IModule, 0, len(req.Names))
+	for _, name := range req.Names {
+		mod, err := factory(name, req.Params)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("factory %s create module %s failed: %w", req.Factory, name, err))
+			return
+		}
+		mods = append(mods, mod)
+	}
+
+	if err := m.app.AddDynamicModulesAtomic(mods...); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	xlog.Infof("admin added dynamic modules %v by factory %s", req.Names, req.Factory)
+	writeJSON(w, http.StatusOK, map[string]any{"added": req.Names})
+}
+
+// handleRemoveModule 卸载 name 查询参数指定的动态模块。
+func (m *Module) handleRemoveModule(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("module name cannot be empty"))
+		return
+	}
+	if err := m.app.TryRemoveDynamicModule(name); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, core.ErrModuleNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, fmt.Errorf("remove dynamic module %s failed: %w", name, err))
+		return
+	}
+	xlog.Infof("admin removed dynamic module %s", name)
+	writeJSON(w, http.StatusOK, map[string]any{"removed": name})
+}
+
+// handleGetLogLevel 返回当前全局日志级别。
+func (m *Module) handleGetLogLevel(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"level": xlog.GetLevel().String()})
+}
+
+// handleSetLogLevel 按 level 查询参数（debug/info/warn/error 等）调整全局日志级别。
+func (m *Module) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(r.URL.Query().Get("level"))); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	xlog.SetLevel(level)
+	xlog.Infof("admin set log level to %s", level)
+	writeJSON(w, http.StatusOK, map[string]any{"level": level.String()})
+}
+
+// handleReloadResults 返回最近一次重新加载的各模块结果。
+func (m *Module) handleReloadResults(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"results": m.app.ReloadResults()})
+}
+
+// handleReload 对实现了 core.IReloadable 的模块执行重新加载，效果与向进程发送 SIGHUP 相同。
+func (m *Module) handleReload(w http.ResponseWriter, r *http.Request) {
+	results, err := m.app.Reload(r.Context())
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	xlog.Infof("admin triggered reload of %d modules", len(results))
+	writeJSON(w, http.StatusOK, map[string]any{"results": results})
+}
+
+// handleMessages 返回 chanrpc 全局消息注册表，按消息 ID 升序排列。
+func (m *Module) handleMessages(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, chanrpc.Messages())
+}
+
+// writeJSON 以 JSON 格式写出响应。
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		xlog.Warnf("admin write response err %v", err)
+	}
+}
+
+// writeError 以 JSON 格式写出错误响应。
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]any{"error": err.Error()})
+}
@@ -1,12 +1,22 @@
 package core
 
 import (
+	"context"
+	"io"
+	"time"
+
 	"github.com/wildmap/utility/core/chanrpc"
 )
 
 // defaultApp 全局默认应用实例（包级单例），供包级函数直接使用。
 // 单例模式避免调用方持有实例引用，简化了典型场景下的使用方式（main 函数直接调用 core.Run）。
-var defaultApp = newApp()
+// 需要在同一进程中运行多个相互独立的应用（如并行测试）时，使用 NewApp 创建独立实例。
+var defaultApp = NewApp()
+
+// Default 返回全局默认应用实例，包级函数均代理到该实例。
+func Default() *App {
+	return defaultApp
+}
 
 // Register 向全局默认应用实例注册静态模块。
 //
@@ -16,13 +26,64 @@ func Register(mods ...IModule) error {
 	return defaultApp.Register(mods...)
 }
 
+// SetInitWorkers 设置全局默认应用实例中模块 OnInit 的最大并发数，须在 Run 之前调用。
+//
+// 默认值为 1（串行初始化）。互不依赖的模块会被并发初始化，任一模块初始化失败时，
+// 已初始化成功的模块会被调用 OnDestroy 回滚。
+func SetInitWorkers(n int) {
+	defaultApp.SetInitWorkers(n)
+}
+
 // Run 向全局默认应用实例注册模块并启动，同时监听系统退出信号（SIGINT/SIGTERM）。
 //
 // 函数会阻塞当前 goroutine 直至收到退出信号，收到后执行优雅关闭流程。
 // 适合在 main 函数中直接调用，是应用的主入口点。
-// SIGHUP 信号不会触发关闭，可用于通知应用重新加载配置（业务层自行监听处理）。
-func Run(mods ...IModule) {
-	defaultApp.Run(mods...)
+// SIGHUP 信号不会触发关闭，而是调用实现了 IReloadable 的模块的 OnReload 重新加载配置。
+// 返回每个模块的关闭结果，调用方可据此判断是否存在超时未退出或销毁异常的模块；启动失败时返回 nil。
+func Run(mods ...IModule) []ShutdownResult {
+	return defaultApp.Run(mods...)
+}
+
+// SetShutdownBudget 设置全局默认应用实例关闭流程的总时间预算，须在 Run 之前调用，d 不大于 0 表示不限制。
+func SetShutdownBudget(d time.Duration) {
+	defaultApp.SetShutdownBudget(d)
+}
+
+// SetShutdownPolicy 设置全局默认应用实例中模块关闭超时后的处理策略，须在 Run 之前调用。
+func SetShutdownPolicy(p ShutdownPolicy) {
+	defaultApp.SetShutdownPolicy(p)
+}
+
+// Reload 对全局默认应用实例中实现了 IReloadable 的模块执行重新加载，与收到 SIGHUP 信号时的行为一致。
+//
+// 返回各模块的重新加载结果；已有重新加载正在执行时返回 ErrReloadInProgress。
+func Reload(ctx context.Context) ([]ReloadResult, error) {
+	return defaultApp.Reload(ctx)
+}
+
+// ReloadResults 返回全局默认应用实例最近一次重新加载的各模块结果。
+func ReloadResults() []ReloadResult {
+	return defaultApp.ReloadResults()
+}
+
+// Threads 返回全局默认应用实例的系统线程使用情况。
+func Threads() ThreadUsage {
+	return defaultApp.Threads()
+}
+
+// AddLifecycleHook 为全局默认应用实例注册模块生命周期事件回调，对静态模块和动态模块均生效。
+func AddLifecycleHook(hook LifecycleHook) {
+	defaultApp.AddLifecycleHook(hook)
+}
+
+// Broadcast 向全局默认应用实例中所有注册了 req 消息类型的模块投递单向消息，返回每个目标的投递结果。
+func Broadcast(req any) []CastResult {
+	return defaultApp.Broadcast(req)
+}
+
+// Multicast 向全局默认应用实例中名称满足 selector 且注册了 req 消息类型的模块投递单向消息，返回每个目标的投递结果。
+func Multicast(selector Selector, req any) []CastResult {
+	return defaultApp.Multicast(selector, req)
 }
 
 // GetState 获取全局默认应用实例的当前运行状态。
@@ -40,6 +101,32 @@ func Stats() string {
 	return defaultApp.Stats()
 }
 
+// Metrics 获取全局默认应用实例中所有模块的结构化指标快照。
+//
+// 包含 ChanCall/ChanAsyncRet 积压与容量、待回调的异步调用数、活跃定时器数、
+// 各消息 Handler 的执行耗时直方图及 panic 次数，无需再解析 Stats 的字符串输出。
+func Metrics() []ModuleMetrics {
+	return defaultApp.Metrics()
+}
+
+// WritePrometheus 以 Prometheus 文本暴露格式输出全局默认应用实例的模块指标，可直接用于 /metrics 接口。
+func WritePrometheus(w io.Writer) error {
+	return defaultApp.WritePrometheus(w)
+}
+
+// Health 获取全局默认应用实例的健康状态，包含应用整体及各模块的存活性与就绪性。
+//
+// 模块可通过实现 IHealthChecker 上报自定义检查结果；整体 Ready 仅在应用处于运行状态、
+// 且所有模块的主循环均已开始服务并通过就绪检查后才为 true，适合作为编排系统的就绪探针。
+func Health() HealthReport {
+	return defaultApp.Health()
+}
+
+// SetHealthCheckInterval 设置全局默认应用实例的健康检查轮询周期，须在 Run 之前调用。
+func SetHealthCheckInterval(d time.Duration) {
+	defaultApp.SetHealthCheckInterval(d)
+}
+
 // GetChanRPC 通过模块名称获取对应模块的 ChanRPC 服务端，用于跨模块消息投递。
 //
 // 优先在静态模块中查找，未命中则查找动态模块。
@@ -63,10 +150,25 @@ func AddDynamicModules(mods ...IModule) error {
 	return defaultApp.AddDynamicModules(mods...)
 }
 
+// AddDynamicModulesAtomic 向运行中的全局默认应用实例以"全部成功或全部回滚"的语义动态添加一批模块。
+//
+// 任一模块初始化失败时，本批次已启动的模块会被停止、销毁并移除，
+// 函数返回错误时应用的动态模块集合与调用前保持一致。
+func AddDynamicModulesAtomic(mods ...IModule) error {
+	return defaultApp.AddDynamicModulesAtomic(mods...)
+}
+
 // RemoveDynamicModule 从全局默认应用实例中同步移除并销毁指定名称的动态模块。
 //
 // 操作为同步阻塞：cancel（发停止信号）→ wg.Wait（等待 goroutine 退出）→ OnDestroy（清理资源）→ 从 map 移除。
 // 调用方会等待模块完全停止后才返回，确保所有资源在函数返回前已被完整清理。
+// 仍有运行中的动态模块依赖该模块时拒绝移除并返回 false。
 func RemoveDynamicModule(name string) bool {
 	return defaultApp.RemoveDynamicModule(name)
 }
+
+// TryRemoveDynamicModule 从全局默认应用实例中同步移除并销毁指定名称的动态模块，
+// 返回失败原因：ErrModuleNotFound 或 ErrModuleInUse。
+func TryRemoveDynamicModule(name string) error {
+	return defaultApp.TryRemoveDynamicModule(name)
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"path"
+	"strings"
+
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+// Selector 多播目标选择器，根据模块名称和类型（static 或 dynamic）判断是否投递。
+type Selector func(name, kind string) bool
+
+// MatchPrefix 返回选择名称以 prefix 开头的模块的选择器。
+func MatchPrefix(prefix string) Selector {
+	return func(name, _ string) bool {
+		return strings.HasPrefix(name, prefix)
+	}
+}
+
+// MatchPattern 返回按 path.Match 通配规则（如 "battle-*"）选择模块的选择器，pattern 非法时不选择任何模块。
+func MatchPattern(pattern string) Selector {
+	return func(name, _ string) bool {
+		ok, err := path.Match(pattern, name)
+		return err == nil && ok
+	}
+}
+
+// MatchDynamic 返回仅选择动态模块的选择器，selector 为 nil 时选择全部动态模块。
+func MatchDynamic(selector Selector) Selector {
+	return func(name, kind string) bool {
+		return kind == "dynamic" && (selector == nil || selector(name, kind))
+	}
+}
+
+// CastResult 单个目标模块的投递结果。
+type CastResult struct {
+	Name string // 目标模块名称
+	Err  error  // 投递错误（如 chanrpc.ErrServerClosed、队列已满），nil 表示消息已进入目标队列
+}
+
+// Broadcast 向所有注册了 req 消息类型的模块（静态模块在前，动态模块在后）投递单向消息，返回每个目标的投递结果。
+//
+// 未注册该消息类型（且未通过 chanrpc.Server.AcceptBroadcast 声明接收）或不支持 RPC 的模块不视为目标，不出现在结果中。
+func (a *App) Broadcast(req any) []CastResult {
+	return a.multicast(a.caster, nil, "", req)
+}
+
+// Multicast 向名称满足 selector 且注册了 req 消息类型的模块投递单向消息，返回每个目标的投递结果。
+//
+// selector 为 nil 时等同于 Broadcast。与 Cast 不同，队列已满等投递失败不记录日志，而是逐个返回给调用方处理。
+func (a *App) Multicast(selector Selector, req any) []CastResult {
+	return a.multicast(a.caster, selector, "", req)
+}
+
+// multicast 通过 client 向选中的模块逐个投递 req，exclude 为发送方自身的模块名，不向其投递。
+func (a *App) multicast(client *chanrpc.Client, selector Selector, exclude string, req any) []CastResult {
+	type target struct {
+		name   string
+		server *chanrpc.Server
+	}
+	var targets []target
+	collect := func(kind string, wrapper *moduleWrapper) {
+		name := wrapper.Name()
+		if name == exclude || (selector != nil && !selector(name, kind)) {
+			return
+		}
+		if server := wrapper.ChanRPC(); server != nil && server.Handles(req) {
+			targets = append(targets, target{name: name, server: server})
+		}
+	}
+
+	a.RLock()
+	for _, wrapper := range a.modules {
+		collect("static", wrapper)
+	}
+	a.RUnlock()
+	a.dynamicModules.Range(func(key, value any) bool {
+		if wrapper, ok := value.(*moduleWrapper); ok {
+			collect("dynamic", wrapper)
+		}
+		return true
+	})
+
+	// 在锁外投递，避免目标队列阻塞时长时间持有模块表读锁
+	results := make([]CastResult, 0, len(targets))
+	for _, t := range targets {
+		results = append(results, CastResult{Name: t.name, Err: client.TryCast(t.server, req)})
+	}
+	return results
+}
+
+// Broadcast 向除自身外所有注册了 req 消息类型的模块投递单向消息，返回每个目标的投递结果。
+func (s *Skeleton) Broadcast(req any) []CastResult {
+	return s.App().multicast(s.client, nil, s.name, req)
+}
+
+// Multicast 向除自身外名称满足 selector 且注册了 req 消息类型的模块投递单向消息，返回每个目标的投递结果。
+//
+// 如通知所有战斗实例：s.Multicast(core.MatchPattern("battle-*"), &BattleEvent{...})。
+func (s *Skeleton) Multicast(selector Selector, req any) []CastResult {
+	return s.App().multicast(s.client, selector, s.name, req)
+}
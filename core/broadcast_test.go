@@ -0,0 +1,61 @@
+package core
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+// skeletonModule 内嵌 Skeleton 的测试模块。
+type skeletonModule struct {
+	*Skeleton
+}
+
+func (m *skeletonModule) OnInit() error { return nil }
+
+func (m *skeletonModule) OnDestroy() {}
+
+type broadcastEvent struct{}
+
+func TestBroadcastTargets(t *testing.T) {
+	app := NewApp()
+	nop := func(*chanrpc.CallInfo) *chanrpc.RetInfo { return nil }
+
+	registered := &skeletonModule{NewSkeleton("registered")}
+	_ = registered.RegisterChanRPC(&broadcastEvent{}, nop)
+	// 仅设置默认处理函数的代理不是广播目标
+	proxy := &skeletonModule{NewSkeleton("proxy")}
+	proxy.ChanRPC().SetDefaultHandler(nop)
+	accepting := &skeletonModule{NewSkeleton("accepting")}
+	accepting.ChanRPC().SetDefaultHandler(nop)
+	accepting.ChanRPC().AcceptBroadcast(&broadcastEvent{})
+	other := &skeletonModule{NewSkeleton("other")}
+	other.ChanRPC().SetDefaultHandler(nop)
+	other.ChanRPC().AcceptBroadcast(&struct{ Other int }{})
+	all := &skeletonModule{NewSkeleton("all")}
+	all.ChanRPC().AcceptBroadcast()
+
+	for _, m := range []IModule{registered, proxy, accepting, other, all} {
+		app.modules = append(app.modules, app.newModuleWrapper("static", m))
+	}
+
+	var names []string
+	for _, res := range app.Broadcast(&broadcastEvent{}) {
+		if res.Err != nil {
+			t.Errorf("cast to %s err = %v", res.Name, res.Err)
+		}
+		names = append(names, res.Name)
+	}
+	if want := []string{"registered", "accepting", "all"}; !slices.Equal(names, want) {
+		t.Errorf("broadcast targets = %v, want %v", names, want)
+	}
+
+	names = names[:0]
+	for _, res := range registered.Multicast(MatchPattern("a*"), &broadcastEvent{}) {
+		names = append(names, res.Name)
+	}
+	if want := []string{"accepting", "all"}; !slices.Equal(names, want) {
+		t.Errorf("multicast targets = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,309 @@
+package chanrpc
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+// defaultBlockTimeout 阻塞投递的默认最长等待时间，未携带 ctx 的同步调用和 OverflowBlock 策略使用。
+const defaultBlockTimeout = 5 * time.Second
+
+// OverflowPolicy 调用通道已满时的处理策略。
+type OverflowPolicy int32
+
+const (
+	// OverflowDefault 默认策略：同步调用阻塞等待（5 秒或 ctx 截止时间），异步调用与 Cast 立即返回 ErrQueueFull。
+	OverflowDefault OverflowPolicy = iota
+	// OverflowReject 所有调用立即返回 ErrQueueFull，调用方据此自行限流或降级。
+	OverflowReject
+	// OverflowBlock 所有调用（含异步调用与 Cast）阻塞等待通道空位，超过 Timeout 或 ctx 结束时返回错误。
+	// 在事件循环中发起调用会阻塞调用方模块，仅适合生产者为独立 goroutine（如网络读协程）的场景。
+	OverflowBlock
+	// OverflowDropOldest 丢弃通道中最早的调用（向其调用方回包 ErrCallDropped）以腾出空位，新调用总能入队。
+	// 适合只关心最新状态的消息，如位置同步、状态快照。
+	OverflowDropOldest
+	// OverflowSpill 将放不下的调用转入无界溢出队列，出队顺序保持 FIFO；
+	// 溢出队列长度达到 HighWater 时回调 OnHighWater，由业务决定告警或限流，内存增长需自行监控。
+	OverflowSpill
+)
+
+// String 返回溢出策略的可读名称，用于日志和指标标签。
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDefault:
+		return "default"
+	case OverflowReject:
+		return "reject"
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowSpill:
+		return "spill"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(p))
+	}
+}
+
+// Backpressure Server 的背压配置，对全部优先级通道生效。
+type Backpressure struct {
+	Policy      OverflowPolicy         // 通道已满时的处理策略
+	Timeout     time.Duration          // OverflowBlock 的最长等待时间，不大于 0 时使用 5 秒；调用携带 ctx 时以较早者为准
+	HighWater   int                    // OverflowSpill 溢出队列的高水位，不大于 0 表示不回调
+	OnHighWater func(e HighWaterEvent) // 溢出队列长度达到 HighWater 时在投递方 goroutine 中同步调用，不应阻塞
+}
+
+// HighWaterEvent 溢出队列触及高水位的事件。
+type HighWaterEvent struct {
+	Server   string   // 服务端名称
+	Priority Priority // 触及高水位的通道优先级
+	Len      int      // 当前溢出队列长度
+}
+
+// backpressureStats 各背压事件的累计次数。
+type backpressureStats struct {
+	rejected  atomic.Uint64
+	blocked   atomic.Uint64
+	timeouts  atomic.Uint64
+	dropped   atomic.Uint64
+	spilled   atomic.Uint64
+	highWater atomic.Uint64
+}
+
+// BackpressureMetrics Server 的背压指标快照。
+type BackpressureMetrics struct {
+	Policy    string `json:"policy"`     // 当前溢出策略
+	Rejected  uint64 `json:"rejected"`   // 因通道已满被拒绝的调用次数
+	Blocked   uint64 `json:"blocked"`    // 因通道已满进入等待的调用次数
+	Timeouts  uint64 `json:"timeouts"`   // 等待超时或 ctx 结束而投递失败的调用次数
+	Dropped   uint64 `json:"dropped"`    // OverflowDropOldest 丢弃的旧调用次数
+	Spilled   uint64 `json:"spilled"`    // 进入溢出队列的调用次数
+	HighWater uint64 `json:"high_water"` // 溢出队列触及高水位的次数
+}
+
+// overflowQueue 单条优先级通道的无界溢出队列，n 镜像 items 长度，供 Poll 无锁判断是否为空。
+type overflowQueue struct {
+	mu    sync.Mutex
+	items []*CallInfo
+	n     atomic.Int64
+}
+
+// SetBackpressure 设置通道已满时的处理策略，应在模块开始服务前调用（通常在 OnInit 中）。
+func (s *Server) SetBackpressure(bp Backpressure) {
+	s.backpressure.Store(&bp)
+}
+
+// enqueue 按优先级和背压策略将调用投递到对应通道，block 表示调用方为同步调用（仅影响 OverflowDefault）。
+//
+// 开启了请求合并的消息类型先尝试合并到相同的在途请求；作为新 leader 投递失败时，期间合并进来的调用以同样的错误结束。
+func (s *Server) enqueue(ci *CallInfo, block bool) (err error) {
+	if s.join(ci) {
+		return nil
+	}
+	if ci.group != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				ci.group.finish(&RetInfo{Err: fmt.Errorf("panic: %v", r)})
+				panic(r)
+			}
+			if err != nil {
+				ci.group.finish(&RetInfo{Err: err})
+			}
+		}()
+	}
+
+	p := s.priorityFor(ci.messageID, ci.ctx)
+	lane := s.lanes[p]
+
+	bp := s.backpressure.Load()
+	policy := OverflowDefault
+	if bp != nil {
+		policy = bp.Policy
+	}
+
+	// 溢出队列非空时新调用也必须排在其后，否则会越过更早的调用
+	if policy == OverflowSpill && s.overflow[p].n.Load() > 0 {
+		return s.spill(p, ci, bp)
+	}
+	select {
+	case lane <- ci:
+		return nil
+	default:
+	}
+
+	switch policy {
+	case OverflowReject:
+		return s.reject(ci)
+	case OverflowBlock:
+		timeout := bp.Timeout
+		if timeout <= 0 {
+			timeout = defaultBlockTimeout
+		}
+		return s.wait(lane, ci, timeout)
+	case OverflowDropOldest:
+		return s.dropOldest(lane, ci)
+	case OverflowSpill:
+		return s.spill(p, ci, bp)
+	default:
+		if !block {
+			return s.reject(ci)
+		}
+		// 携带 ctx 的同步调用等待至调用方截止时间，而非固定超时
+		if ci.ctx != nil {
+			return s.wait(lane, ci, 0)
+		}
+		return s.wait(lane, ci, defaultBlockTimeout)
+	}
+}
+
+// reject 记录一次拒绝并返回包含消息类型的 ErrQueueFull。
+func (s *Server) reject(ci *CallInfo) error {
+	s.bpStats.rejected.Add(1)
+	reqType := "unknown"
+	if ci.Request != nil {
+		reqType = reflect.TypeOf(ci.Request).String()
+	}
+	return fmt.Errorf("%w, server %s msg %v %+v", ErrQueueFull, s.Name(), reqType, ci.Request)
+}
+
+// wait 阻塞等待通道空位，timeout 不大于 0 时仅受调用方 ctx 约束。
+func (s *Server) wait(lane chan *CallInfo, ci *CallInfo, timeout time.Duration) error {
+	s.bpStats.blocked.Add(1)
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	var done <-chan struct{}
+	if ci.ctx != nil {
+		done = ci.ctx.Done()
+	}
+
+	select {
+	case lane <- ci:
+		return nil
+	case <-expired:
+		s.bpStats.timeouts.Add(1)
+		return ErrCallTimeout
+	case <-done:
+		s.bpStats.timeouts.Add(1)
+		return ci.ctx.Err()
+	}
+}
+
+// dropOldest 反复取出通道中最早的调用并回包 ErrCallDropped，直到新调用入队成功。
+func (s *Server) dropOldest(lane chan *CallInfo, ci *CallInfo) error {
+	for {
+		select {
+		case lane <- ci:
+			return nil
+		default:
+		}
+		select {
+		case victim, ok := <-lane:
+			if !ok {
+				return ErrServerClosed
+			}
+			s.bpStats.dropped.Add(1)
+			_ = victim.ret(&RetInfo{Err: ErrCallDropped})
+		default:
+		}
+	}
+}
+
+// spill 将调用追加到溢出队列，溢出队列为空且通道有空位时直接入通道。
+func (s *Server) spill(p Priority, ci *CallInfo, bp *Backpressure) error {
+	n, err := s.overflow[p].push(s, s.lanes[p], ci)
+	if err != nil || n == 0 {
+		return err
+	}
+	s.bpStats.spilled.Add(1)
+	if bp.HighWater > 0 && n == bp.HighWater && bp.OnHighWater != nil {
+		s.bpStats.highWater.Add(1)
+		notifyHighWater(bp.OnHighWater, HighWaterEvent{Server: s.Name(), Priority: p, Len: n})
+	}
+	return nil
+}
+
+// push 在锁内完成关闭检查与入队，返回入队后的溢出队列长度，直接进入通道时返回 0。
+//
+// 关闭检查与追加同在锁内：Close 置位 closed 后再加锁排空，保证不会有调用在排空之后滞留于溢出队列。
+func (q *overflowQueue) push(s *Server, lane chan *CallInfo, ci *CallInfo) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if s.closed.Load() {
+		return 0, ErrServerClosed
+	}
+	if len(q.items) == 0 {
+		select {
+		case lane <- ci:
+			return 0, nil
+		default:
+		}
+	}
+	q.items = append(q.items, ci)
+	q.n.Store(int64(len(q.items)))
+	return len(q.items), nil
+}
+
+// pop 取出溢出队列中最早的调用，队列为空时返回 nil。
+func (q *overflowQueue) pop() *CallInfo {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return nil
+	}
+	ci := q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	q.n.Store(int64(len(q.items)))
+	return ci
+}
+
+// drain 清空溢出队列并返回其中的全部调用。
+func (q *overflowQueue) drain() []*CallInfo {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := q.items
+	q.items = nil
+	q.n.Store(0)
+	return items
+}
+
+// notifyHighWater 调用高水位回调，并捕获回调中的 panic，避免影响投递方。
+func notifyHighWater(f func(e HighWaterEvent), e HighWaterEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			xlog.Errorf("chanrpc server %s high water callback panic recovered, panic %v\n%s", e.Server, r, string(debug.Stack()))
+		}
+	}()
+	f(e)
+}
+
+// backpressureMetrics 返回背压指标快照。
+func (s *Server) backpressureMetrics() BackpressureMetrics {
+	policy := OverflowDefault
+	if bp := s.backpressure.Load(); bp != nil {
+		policy = bp.Policy
+	}
+	return BackpressureMetrics{
+		Policy:    policy.String(),
+		Rejected:  s.bpStats.rejected.Load(),
+		Blocked:   s.bpStats.blocked.Load(),
+		Timeouts:  s.bpStats.timeouts.Load(),
+		Dropped:   s.bpStats.dropped.Load(),
+		Spilled:   s.bpStats.spilled.Load(),
+		HighWater: s.bpStats.highWater.Load(),
+	}
+}
@@ -0,0 +1,89 @@
+package chanrpc
+
+import (
+	"errors"
+	"testing"
+)
+
+type spillMsg struct{ N int }
+
+func TestSpillFIFO(t *testing.T) {
+	s := NewServer(2)
+	_ = s.Register(&spillMsg{}, func(*CallInfo) *RetInfo { return nil })
+	var events []HighWaterEvent
+	s.SetBackpressure(Backpressure{
+		Policy:      OverflowSpill,
+		HighWater:   3,
+		OnHighWater: func(e HighWaterEvent) { events = append(events, e) },
+	})
+	c := NewClient(1)
+
+	next := 0
+	cast := func(n int) {
+		for range n {
+			if err := c.TryCast(s, &spillMsg{N: next}); err != nil {
+				t.Fatalf("cast %d err = %v", next, err)
+			}
+			next++
+		}
+	}
+	var got []int
+	poll := func(n int) {
+		for range n {
+			got = append(got, s.Poll().Request.(*spillMsg).N)
+		}
+	}
+
+	// 通道出现空位后，新调用仍排在溢出队列之后
+	cast(5)
+	poll(1)
+	cast(2)
+	poll(6)
+	for i, n := range got {
+		if n != i {
+			t.Fatalf("poll order = %v, want FIFO", got)
+		}
+	}
+
+	m := s.Metrics().Backpressure
+	if m.Spilled != 5 || m.HighWater != 1 || len(events) != 1 || events[0].Len != 3 {
+		t.Errorf("spilled %d high water %d events %+v, want 5, 1 and one event at 3", m.Spilled, m.HighWater, events)
+	}
+}
+
+func TestOverflowReject(t *testing.T) {
+	s := NewServer(1)
+	_ = s.Register(&spillMsg{}, func(*CallInfo) *RetInfo { return nil })
+	s.SetBackpressure(Backpressure{Policy: OverflowReject})
+	c := NewClient(1)
+
+	if err := c.TryCast(s, &spillMsg{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.TryCast(s, &spillMsg{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("cast to full server err = %v, want %v", err, ErrQueueFull)
+	}
+	if ri := c.Call(s, &spillMsg{}); !errors.Is(ri.Err, ErrQueueFull) {
+		t.Errorf("call to full server err = %v, want %v", ri.Err, ErrQueueFull)
+	}
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	s := NewServer(1)
+	_ = s.Register(&spillMsg{}, func(*CallInfo) *RetInfo { return nil })
+	s.SetBackpressure(Backpressure{Policy: OverflowDropOldest})
+	c := NewClient(2)
+
+	if err := c.AsyncCall(s, &spillMsg{N: 0}, func(*RetInfo) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.TryCast(s, &spillMsg{N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if ri := <-c.ChanAsyncRet; !errors.Is(ri.Err, ErrCallDropped) {
+		t.Errorf("dropped call err = %v, want %v", ri.Err, ErrCallDropped)
+	}
+	if ci := s.Poll(); ci.Request.(*spillMsg).N != 1 {
+		t.Errorf("remaining call = %+v, want newest", ci.Request)
+	}
+}
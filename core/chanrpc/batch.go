@@ -0,0 +1,146 @@
+package chanrpc
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+// BatchHandler 批量处理函数，一次接收同一消息类型的多个调用，在 Server 所在模块的 goroutine 中执行。
+//
+// 返回值与 batch 按下标一一对应，nil 元素或缺少的元素表示空响应；Cast 调用的结果被丢弃。
+// 不支持 CallInfo.Defer，需要等待其他模块结果的消息应使用普通的 Handler。
+// 批量处理函数 panic 时，本批次的全部调用均回包错误。
+type BatchHandler func(batch []*CallInfo) []*RetInfo
+
+// batchQueue 单个消息类型累积的待批量处理调用，仅在事件循环中读写。
+type batchQueue struct {
+	handler BatchHandler
+	max     int
+	calls   []*CallInfo
+}
+
+// batching 批量处理的状态，仅在事件循环中读写。
+//
+// 首个调用进入批次时记录此刻通道中积压的调用数 due，此后每执行一条调用减一，
+// 积压的调用全部执行完毕（或通道已空）时统一执行各消息类型的批量处理函数，
+// 因此一个批次包含的是首个调用入队时已在排队的同类型调用，批次的等待时间不超过一轮积压的处理时间。
+type batching struct {
+	queues map[uint32]*batchQueue // 消息 ID → 待批量处理的调用，初始化后键集合只读
+	active bool                   // 是否有调用在等待批量处理
+	due    int                    // 距离执行批量处理还需执行的调用数
+}
+
+// RegisterBatch 注册消息的批量处理函数，该类型的调用出队后不再逐条处理，而是累积到批次中一次交给 f。
+//
+// maxBatch 为单个批次的最大调用数，达到后立即执行，不大于 0 表示不限制。
+// 服务端拦截器仍对每个调用逐一执行，调用进入批次时 next 返回 nil，ci.Deferred() 为 true。
+// 注册规则与 Register 相同，可与 EnableCoalescing 同时使用。
+func (s *Server) RegisterBatch(message any, f BatchHandler, maxBatch int) error {
+	if f == nil {
+		return ErrRegisterHandlerNil
+	}
+	q := &batchQueue{handler: f, max: maxBatch}
+	if err := s.Register(message, s.batcher(q)); err != nil {
+		return err
+	}
+	if s.batching.queues == nil {
+		s.batching.queues = make(map[uint32]*batchQueue)
+	}
+	s.batching.queues[MessageID(message)] = q
+	return nil
+}
+
+// batcher 返回将调用加入批次的 Handler。
+func (s *Server) batcher(q *batchQueue) Handler {
+	return func(ci *CallInfo) *RetInfo {
+		ci.deferred = true
+		q.calls = append(q.calls, ci)
+		if !s.batching.active {
+			s.batching.active = true
+			s.batching.due = s.Len()
+		}
+		if q.max > 0 && len(q.calls) >= q.max {
+			s.runBatch(q)
+		}
+		return nil
+	}
+}
+
+// tickBatch 在每条调用执行后推进批次，积压的调用已全部执行或通道已空时执行全部批次。
+func (s *Server) tickBatch() {
+	if !s.batching.active {
+		return
+	}
+	s.batching.due--
+	if s.batching.due >= 0 && s.Len() > 0 {
+		return
+	}
+	s.batching.active = false
+	for _, q := range s.batching.queues {
+		if len(q.calls) > 0 {
+			s.runBatch(q)
+		}
+	}
+}
+
+// runBatch 取出批次中调用方仍在等待的调用执行批量处理函数，并逐一回包。
+func (s *Server) runBatch(q *batchQueue) {
+	batch := make([]*CallInfo, 0, len(q.calls))
+	for _, ci := range q.calls {
+		if ci.Abandoned() && !ci.group.shared() {
+			s.abandoned.Add(1)
+			_ = ci.ret(&RetInfo{Err: fmt.Errorf("chanrpc message_id %d abandoned by caller: %w", ci.MessageID(), context.Cause(ci.ctx))})
+			continue
+		}
+		batch = append(batch, ci)
+	}
+	clear(q.calls)
+	q.calls = q.calls[:0]
+	if len(batch) == 0 {
+		return
+	}
+
+	// 批次混合了多条调用链，批量处理期间发起的嵌套调用不归属任何一条
+	prev := s.current.Swap(nil)
+	defer s.current.Store(prev)
+
+	s.batches.Add(1)
+	rets, err := s.callBatch(q.handler, batch)
+	for i, ci := range batch {
+		ri := &RetInfo{Err: err}
+		if err == nil && i < len(rets) && rets[i] != nil {
+			ri = rets[i]
+		}
+		_ = ci.ret(ri)
+	}
+}
+
+// callBatch 执行批量处理函数，捕获其中的 panic 并转换为错误。
+func (s *Server) callBatch(f BatchHandler, batch []*CallInfo) (rets []*RetInfo, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			s.panics.Add(1)
+			if hs, ok := s.stats[batch[0].MessageID()]; ok {
+				hs.panics.Add(1)
+			}
+			xlog.Errorf("chanrpc message_id %d batch of %d panic %v\n%s", batch[0].MessageID(), len(batch), err, string(debug.Stack()))
+		}
+	}()
+	return f(batch), nil
+}
+
+// dropBatches 以 err 回包全部等待批量处理的调用，在 Close 时调用。
+func (s *Server) dropBatches(err error) {
+	s.batching.active = false
+	for _, q := range s.batching.queues {
+		for _, ci := range q.calls {
+			_ = ci.ret(&RetInfo{Err: err})
+		}
+		clear(q.calls)
+		q.calls = q.calls[:0]
+	}
+}
@@ -0,0 +1,78 @@
+package chanrpc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+type batchReq struct{ N int }
+
+type panicBatchReq struct{}
+
+func TestBatchFlush(t *testing.T) {
+	s := NewServer(16)
+	var sizes []int
+	err := s.RegisterBatch(&batchReq{}, func(batch []*CallInfo) []*RetInfo {
+		sizes = append(sizes, len(batch))
+		rets := make([]*RetInfo, len(batch))
+		for i, ci := range batch {
+			rets[i] = &RetInfo{Ack: ci.Request.(*batchReq).N}
+		}
+		return rets
+	}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(16)
+
+	got := make([]int, 10)
+	for i := range got {
+		_ = c.AsyncCall(s, &batchReq{N: i}, func(ri *RetInfo) {
+			if ri.Err != nil {
+				t.Errorf("call %d err = %v", i, ri.Err)
+				return
+			}
+			got[i] = ri.Ack.(int)
+		})
+	}
+	drain(s)
+	for range got {
+		c.AsyncCallback(<-c.ChanAsyncRet)
+	}
+
+	if want := []int{4, 4, 2}; !slices.Equal(sizes, want) {
+		t.Errorf("batch sizes = %v, want %v", sizes, want)
+	}
+	for i, n := range got {
+		if n != i {
+			t.Errorf("ack %d = %d", i, n)
+		}
+	}
+	if got := s.Metrics().Batches; got != 3 {
+		t.Errorf("batches = %d, want 3", got)
+	}
+}
+
+func TestBatchPanic(t *testing.T) {
+	s := NewServer(16)
+	_ = s.RegisterBatch(&panicBatchReq{}, func([]*CallInfo) []*RetInfo {
+		panic("boom")
+	}, 0)
+	c := NewClient(16)
+
+	for range 3 {
+		_ = c.AsyncCall(s, &panicBatchReq{}, func(ri *RetInfo) {
+			if ri.Err == nil || !strings.Contains(ri.Err.Error(), "boom") {
+				t.Errorf("call err = %v, want panic error", ri.Err)
+			}
+		})
+	}
+	drain(s)
+	for range 3 {
+		c.AsyncCallback(<-c.ChanAsyncRet)
+	}
+	if got := s.Metrics().Panics; got != 1 {
+		t.Errorf("panics = %d, want 1", got)
+	}
+}
@@ -1,9 +1,9 @@
 package chanrpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"reflect"
 	"runtime/debug"
 	"sync"
 	"sync/atomic"
@@ -18,9 +18,12 @@ import (
 // 在 Close 时等待计数归零，确保模块关闭前所有回调均已执行，防止业务状态不一致。
 // closed 标志在 CAS 语义下保证关闭操作的幂等性，防止关闭后再次发起调用。
 type Client struct {
-	ChanAsyncRet     chan *RetInfo // 异步调用结果的接收通道，容量应与目标 Server.ChanCall 保持量级一致
-	pendingAsyncCall atomic.Int64  // 当前尚未处理完毕的异步调用数量，原子操作保证并发安全
-	closed           atomic.Bool   // 关闭标志，防止关闭后继续发起新的调用
+	ChanAsyncRet     chan *RetInfo       // 异步调用结果的接收通道，容量应与目标 Server.ChanCall 保持量级一致
+	pendingAsyncCall atomic.Int64        // 当前尚未处理完毕的异步调用数量，原子操作保证并发安全
+	closed           atomic.Bool         // 关闭标志，防止关闭后继续发起新的调用
+	callbackPanics   atomic.Uint64       // 异步回调 panic 次数，用于监控
+	owner            *Server             // 所属模块的 Server，用于同步调用死锁检测，nil 表示不检测
+	interceptors     []ClientInterceptor // 客户端拦截器链，初始化后只读
 }
 
 // NewClient 创建指定异步回调通道容量的 ChanRPC 客户端。
@@ -56,6 +59,10 @@ func (c *Client) check(s *Server, request any) (uint32, error) {
 	if messageID <= 0 {
 		return 0, ErrInvalidMsgType
 	}
+	// 请求类型与该 ID 的注册类型不一致说明发生了哈希碰撞，拒绝调用以免路由到错误的处理函数
+	if err := messages.check(messageID, request); err != nil {
+		return messageID, err
+	}
 	return messageID, nil
 }
 
@@ -67,6 +74,7 @@ func (c *Client) check(s *Server, request any) (uint32, error) {
 //
 // 警告：在事件循环中使用 Call 会阻塞本模块对其他消息的处理；
 // 若对端模块同时向本模块发起 Call，则形成循环等待（死锁），生产环境应优先使用 AsyncCall。
+// 通过 SetOwner 设置了所属模块的客户端会在阻塞前检测循环等待，命中时返回 *DeadlockError。
 func (c *Client) Call(s *Server, request any) *RetInfo {
 	messageID, err := c.check(s, request)
 	if err != nil {
@@ -74,19 +82,85 @@ func (c *Client) Call(s *Server, request any) *RetInfo {
 		return &RetInfo{Err: err}
 	}
 
+	leave, err := c.enterSyncCall(s)
+	if err != nil {
+		xlog.Errorf("chanrpc sync call failed message_id %d err %v", messageID, err)
+		return &RetInfo{Err: err}
+	}
+	defer leave()
+
 	// 独立的单元素 channel，容量为 1 保证 Server 回包时不阻塞
 	chanRet := make(chan *RetInfo, 1)
-	err = c.call(s.ChanCall, &CallInfo{
+	ci := &CallInfo{
 		messageID: messageID,
 		Request:   request,
 		chanRet:   chanRet,
-	}, true) // block=true：采用超时阻塞模式投递
+	}
+	err = c.invoke(ci, func(ci *CallInfo) error {
+		return c.call(s, ci, true) // block=true：采用超时阻塞模式投递
+	})
 	if err != nil {
 		xlog.Warnf("chanrpc sync call failed message_id %d err %v", messageID, err)
 		return &RetInfo{Err: err}
 	}
 
 	ri := <-chanRet
+	notifyResult(ci.onResult, ri)
+	return ri
+}
+
+// CallContext 向指定 Server 发起同步 RPC 调用，ctx 的截止时间约束投递、处理和回包的整个往返过程。
+//
+// 与 Call 的区别：
+//   - 投递阶段等待至 ctx 结束，而非固定的 5 秒超时
+//   - 等待响应阶段同样受 ctx 约束，ctx 结束时立即返回 ctx 的错误，而不会永久阻塞
+//   - 服务端可通过 CallInfo.Deadline 读取剩余时间，出队时调用方已放弃的请求会被直接丢弃
+//
+// ctx 未设置截止时间且永不取消时，行为等同于无超时的 Call，调用方应自行保证 ctx 有界。
+func (c *Client) CallContext(ctx context.Context, s *Server, request any) *RetInfo {
+	messageID, err := c.check(s, request)
+	if err != nil {
+		xlog.Warnf("chanrpc sync call failed message_id %d err %v", messageID, err)
+		return &RetInfo{Err: err}
+	}
+	if err = ctx.Err(); err != nil {
+		return &RetInfo{Err: err}
+	}
+
+	leave, err := c.enterSyncCall(s)
+	if err != nil {
+		xlog.Errorf("chanrpc sync call failed message_id %d err %v", messageID, err)
+		return &RetInfo{Err: err}
+	}
+	defer leave()
+
+	chanRet := make(chan *RetInfo, 1)
+	ci := &CallInfo{
+		messageID: messageID,
+		Request:   request,
+		chanRet:   chanRet,
+		ctx:       ctx,
+	}
+	err = c.invoke(ci, func(ci *CallInfo) error {
+		return c.call(s, ci, true)
+	})
+	if err != nil {
+		xlog.Warnf("chanrpc sync call failed message_id %d err %v", messageID, err)
+		return &RetInfo{Err: err}
+	}
+
+	var ri *RetInfo
+	select {
+	case ri = <-chanRet:
+	case <-ctx.Done():
+		// 抢占失败说明响应已写入 chanRet（容量为 1，写入不会阻塞），以实际响应为准
+		if !ci.claimDelivery() {
+			ri = <-chanRet
+		} else {
+			ri = &RetInfo{Err: ctx.Err()}
+		}
+	}
+	notifyResult(ci.onResult, ri)
 	return ri
 }
 
@@ -106,18 +180,78 @@ func (c *Client) AsyncCall(s *Server, request any, callback Callback) error {
 		return err
 	}
 
-	err = c.call(s.ChanCall, &CallInfo{
+	ci := &CallInfo{
 		messageID: messageID,
 		Request:   request,
 		chanRet:   c.ChanAsyncRet, // 使用共享异步回调通道，回调由事件循环统一消费
 		callback:  callback,
-	}, false) // block=false：非阻塞投递，channel 满时立即报错，调用方可据此做流控
+	}
+	err = c.invoke(ci, func(ci *CallInfo) error {
+		// block=false：非阻塞投递，channel 满时立即报错，调用方可据此做流控
+		if err := c.call(s, ci, false); err != nil {
+			return err
+		}
+		c.pendingAsyncCall.Add(1)
+		return nil
+	})
+	if err != nil {
+		xlog.Warnf("chanrpc async call failed message_id %d err %v", messageID, err)
+		return err
+	}
+	return nil
+}
+
+// AsyncCallContext 向指定 Server 发起异步 RPC 调用，ctx 的截止时间约束整个往返过程。
+//
+// 投递语义与 AsyncCall 相同（非阻塞）。ctx 在响应到达前结束时，callback 以 ctx 的错误被调用，
+// 之后到达的响应会被丢弃，保证 callback 恰好执行一次，且仍在调用方事件循环中执行。
+func (c *Client) AsyncCallContext(ctx context.Context, s *Server, request any, callback Callback) error {
+	if callback == nil {
+		return ErrCallbackNil
+	}
+
+	messageID, err := c.check(s, request)
 	if err != nil {
 		xlog.Warnf("chanrpc async call failed message_id %d err %v", messageID, err)
 		return err
 	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	ci := &CallInfo{
+		messageID: messageID,
+		Request:   request,
+		chanRet:   c.ChanAsyncRet,
+		callback:  callback,
+		ctx:       ctx,
+	}
 
-	c.pendingAsyncCall.Add(1)
+	err = c.invoke(ci, func(ci *CallInfo) error {
+		// ctx 先于响应结束时，由超时回调代替服务端向 ChanAsyncRet 投递错误结果；
+		// 须在拦截器注册完结果回调之后、投递到服务端之前注册，保证超时回调和服务端读取 ci 时均已完成赋值
+		c.pendingAsyncCall.Add(1)
+		ci.stopAfter = context.AfterFunc(ctx, func() {
+			if ci.claimDelivery() {
+				_ = ci.deliver(&RetInfo{Err: ctx.Err()})
+			}
+		})
+
+		if err := c.call(s, ci, false); err != nil {
+			if !ci.claimDelivery() {
+				// 投递失败前 ctx 已结束且超时回调已投递错误结果，callback 将以 ctx 的错误执行
+				return nil
+			}
+			ci.stopAfter()
+			c.pendingAsyncCall.Add(-1)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		xlog.Warnf("chanrpc async call failed message_id %d err %v", messageID, err)
+		return err
+	}
 	return nil
 }
 
@@ -128,22 +262,41 @@ func (c *Client) AsyncCall(s *Server, request any, callback Callback) error {
 // Server 处理后直接丢弃结果，不产生任何回调开销。
 // 对 ErrServerNil 不打 warn 日志：允许对端模块尚未就绪时静默丢弃，避免大量误报。
 func (c *Client) Cast(s *Server, request any) {
+	if err := c.TryCast(s, request); err != nil && !errors.Is(err, ErrServerNil) {
+		xlog.Warnf("chanrpc cast failed message_id %d err %v", MessageID(request), err)
+	}
+}
+
+// CastContext 与 Cast 相同，ctx 携带的追踪标识和优先级（见 ContextWithSpan、WithPriority）随消息传递，
+// 消息出队前 ctx 已结束时不再处理。
+func (c *Client) CastContext(ctx context.Context, s *Server, request any) {
+	if err := c.tryCast(ctx, s, request); err != nil && !errors.Is(err, ErrServerNil) {
+		xlog.Warnf("chanrpc cast failed message_id %d err %v", MessageID(request), err)
+	}
+}
+
+// TryCast 与 Cast 语义相同，但将投递失败（如 ErrServerNil、ErrServerClosed、队列已满）返回给调用方而不记录日志。
+//
+// 返回 nil 仅表示消息已进入对端队列，不代表已被处理。
+func (c *Client) TryCast(s *Server, request any) error {
+	return c.tryCast(nil, s, request)
+}
+
+// tryCast 投递单向消息，ctx 为 nil 表示不携带上下文。
+func (c *Client) tryCast(ctx context.Context, s *Server, request any) error {
 	messageID, err := c.check(s, request)
 	if err != nil {
-		if !errors.Is(err, ErrServerNil) {
-			xlog.Warnf("chanrpc cast failed message_id %d err %v", messageID, err)
-		}
-		return
+		return err
 	}
 
-	err = c.call(s.ChanCall, &CallInfo{
+	return c.invoke(&CallInfo{
 		messageID: messageID,
 		Request:   request,
+		ctx:       ctx,
 		// chanRet 和 callback 均为 nil，Server 端处理后不回包
-	}, false)
-	if err != nil {
-		xlog.Warnf("chanrpc cast failed message_id %d err %v", messageID, err)
-	}
+	}, func(ci *CallInfo) error {
+		return c.call(s, ci, false)
+	})
 }
 
 // execCallback 安全执行单个异步回调，通过 recover 捕获回调内部的 panic。
@@ -152,6 +305,7 @@ func (c *Client) Cast(s *Server, request any) {
 func (c *Client) execCallback(ri *RetInfo) {
 	defer func() {
 		if r := recover(); r != nil {
+			c.callbackPanics.Add(1)
 			xlog.Errorf("chanrpc callback panic: %v\n%s", r, string(debug.Stack()))
 		}
 	}()
@@ -167,6 +321,12 @@ func (c *Client) execCallback(ri *RetInfo) {
 // 从而可以无锁安全地访问模块内部状态。
 func (c *Client) AsyncCallback(ri *RetInfo) {
 	c.pendingAsyncCall.Add(-1)
+	if c.owner != nil && ri.origin.IsValid() {
+		// 回调中发起的嵌套调用延续发起异步调用时所处的调用链
+		c.owner.current.Store(&ri.origin)
+		defer c.owner.current.Store(nil)
+	}
+	notifyResult(ri.onResult, ri)
 	c.execCallback(ri)
 }
 
@@ -230,20 +390,20 @@ func (c *Client) PendingCount() int64 {
 	return c.pendingAsyncCall.Load()
 }
 
-// call 将 CallInfo 投递到 Server 的调用通道，支持阻塞和非阻塞两种模式。
+// call 将 CallInfo 按优先级和 Server 的背压策略投递到调用通道。
 //
-// 阻塞模式（block=true，用于 Call）：
-//   - 带 5 秒超时的 select，Server 繁忙时最多等待 5 秒再返回 ErrCallTimeout
+// 默认策略（OverflowDefault）下：
+//   - 阻塞模式（block=true，用于 Call）：通道已满时最多等待 5 秒（携带 ctx 时等待至 ctx 结束）
+//   - 非阻塞模式（block=false，用于 AsyncCall/Cast）：通道已满时立即返回 ErrQueueFull，调用方可做流控或告警
 //
-// 非阻塞模式（block=false，用于 AsyncCall/Cast）：
-//   - 使用 select default 分支，channel 满时立即返回详细错误，调用方可做流控或告警
+// 其他策略见 OverflowPolicy，由 Server.SetBackpressure 设置。
 //
 // panic 恢复：当向已关闭的 channel 写入时触发 panic（Server.Close 后），
 // 通过 recover 捕获并转化为 error 返回；若 chanRet 非空，还会向调用方回包错误，
 // 确保 Call 调用方不会永久阻塞在等待响应上。
-func (c *Client) call(chanCall chan *CallInfo, ci *CallInfo, block bool) (err error) {
-	if chanCall == nil {
-		return ErrCallChannelNil
+func (c *Client) call(s *Server, ci *CallInfo, block bool) (err error) {
+	if s == nil {
+		return ErrServerNil
 	}
 	if ci == nil {
 		return ErrCallInfoNil
@@ -253,7 +413,7 @@ func (c *Client) call(chanCall chan *CallInfo, ci *CallInfo, block bool) (err er
 		if r := recover(); r != nil {
 			err = fmt.Errorf("panic: %v\n%s", r, string(debug.Stack()))
 			xlog.Warnf("chanrpc call panic message_id %d err %v", ci.MessageID(), err)
-			if ci.chanRet != nil {
+			if ci.chanRet != nil && ci.claimDelivery() {
 				// 非阻塞回包，防止 chanRet 已满或已关闭时产生次生 panic
 				select {
 				case ci.chanRet <- &RetInfo{Err: err}:
@@ -263,27 +423,5 @@ func (c *Client) call(chanCall chan *CallInfo, ci *CallInfo, block bool) (err er
 		}
 	}()
 
-	if block {
-		// 阻塞模式：带超时保护，防止 Server 无响应时发送方永久阻塞
-		timer := time.NewTimer(5 * time.Second)
-		defer timer.Stop()
-		select {
-		case chanCall <- ci:
-			return nil
-		case <-timer.C:
-			return ErrCallTimeout
-		}
-	}
-
-	// 非阻塞模式：channel 满时立即返回详细错误，调用方可据此做背压处理或监控告警
-	select {
-	case chanCall <- ci:
-		return nil
-	default:
-		reqType := "unknown"
-		if ci.Request != nil {
-			reqType = reflect.TypeOf(ci.Request).String()
-		}
-		return fmt.Errorf("server chanrpc channel full, msg %v %+v", reqType, ci.Request)
-	}
+	return s.enqueue(ci, block)
 }
@@ -0,0 +1,74 @@
+package chanrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type slowReq struct{ Delay time.Duration }
+
+func TestCallContextTimeout(t *testing.T) {
+	s := NewServer(16)
+	_ = s.Register(&slowReq{}, func(ci *CallInfo) *RetInfo {
+		time.Sleep(ci.Request.(*slowReq).Delay)
+		return &RetInfo{Ack: "done"}
+	})
+	testServer(t, s, nil)
+	c := NewClient(16)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if ri := c.CallContext(ctx, s, &slowReq{Delay: 100 * time.Millisecond}); !errors.Is(ri.Err, context.DeadlineExceeded) {
+		t.Fatalf("call err = %v, want %v", ri.Err, context.DeadlineExceeded)
+	}
+	if ri := c.CallContext(context.Background(), s, &slowReq{}); ri.Err != nil || ri.Ack != "done" {
+		t.Fatalf("call = %v %v, want done", ri.Ack, ri.Err)
+	}
+}
+
+func TestAsyncCallContextAbandoned(t *testing.T) {
+	s := NewServer(16)
+	executed := make(chan struct{}, 1)
+	_ = s.Register(&slowReq{}, func(ci *CallInfo) *RetInfo {
+		executed <- struct{}{}
+		return nil
+	})
+	c := NewClient(16)
+
+	// 事件循环尚未运行，调用在队列中等待至超时
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	results := make(chan error, 2)
+	if err := c.AsyncCallContext(ctx, s, &slowReq{}, func(ri *RetInfo) { results <- ri.Err }); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case ri := <-c.ChanAsyncRet:
+		c.AsyncCallback(ri)
+	case <-time.After(time.Second):
+		t.Fatal("callback not delivered after ctx timeout")
+	}
+	if err := <-results; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("callback err = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// 出队时调用方已放弃，处理函数不再执行，响应也不会再次送达
+	testServer(t, s, nil)
+	deadline := time.Now().Add(time.Second)
+	for s.Metrics().Abandoned != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("abandoned = %d, want 1", s.Metrics().Abandoned)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-executed:
+		t.Error("handler executed for abandoned call")
+	case ri := <-c.ChanAsyncRet:
+		t.Errorf("second result delivered: %+v", ri)
+	default:
+	}
+}
@@ -0,0 +1,113 @@
+package chanrpc
+
+import (
+	"fmt"
+	"sync"
+)
+
+// KeyFunc 从请求中提取合并键，消息 ID 相同且合并键相同的请求视为相同请求。
+//
+// 在发起调用的 goroutine 中执行，只能读取请求内容，不得访问模块状态。
+type KeyFunc func(request any) string
+
+// coalesceKey 合并请求的标识。
+type coalesceKey struct {
+	id  uint32
+	key string
+}
+
+// coalesceGroup 一组合并的相同请求：首个请求（leader）正常入队执行，其余请求（followers）挂在 leader 上等待结果。
+type coalesceGroup struct {
+	s         *Server
+	key       coalesceKey
+	followers []*CallInfo // 受 s.coalesceMu 保护
+}
+
+// coalescing 合并请求的状态，mu 保护 inflight。
+type coalescing struct {
+	keys     map[uint32]KeyFunc // 消息 ID → 合并键函数，初始化后只读
+	mu       sync.Mutex
+	inflight map[coalesceKey]*CallInfo // 合并键 → 已入队尚未回包的 leader
+}
+
+// EnableCoalescing 为已注册的消息类型开启请求合并，须在开始服务前调用（通常在 OnInit 中）。
+//
+// 开启后，与已入队（含正在执行或通过 Defer 延迟响应）且尚未回包的请求消息 ID 相同、合并键相同的新调用不再入队，
+// 而是共享该请求的一次 Handler 执行，结果（同一个 Ack 实例，调用方须只读使用）分发给全部调用方。
+// key 为 nil 时同类型的全部请求视为相同。只适用于幂等的查询类消息；Cast 不参与合并。
+//
+// 被合并的调用不经过服务端拦截器，也不单独生成调用记录；执行请求的调用方超时或取消时，只要仍有其他调用方在等待，请求照常执行。
+func (s *Server) EnableCoalescing(message any, key KeyFunc) error {
+	if message == nil {
+		return ErrRegisterMsgNil
+	}
+	id := MessageID(message)
+	if _, ok := s.functions[id]; !ok {
+		return fmt.Errorf("chanrpc coalesce: message %T not registered", message)
+	}
+	if key == nil {
+		key = func(any) string { return "" }
+	}
+	if s.coalescing.keys == nil {
+		s.coalescing.keys = make(map[uint32]KeyFunc)
+		s.coalescing.inflight = make(map[coalesceKey]*CallInfo)
+	}
+	s.coalescing.keys[id] = key
+	return nil
+}
+
+// join 尝试将调用合并到相同的在途请求，返回 true 表示已合并、无需入队；
+// 否则在开启了合并的消息类型上将 ci 登记为新的 leader。
+func (s *Server) join(ci *CallInfo) bool {
+	keyFunc, ok := s.coalescing.keys[ci.messageID]
+	if !ok || ci.IsCast() {
+		return false
+	}
+	k := coalesceKey{id: ci.messageID, key: keyFunc(ci.Request)}
+
+	s.coalescing.mu.Lock()
+	defer s.coalescing.mu.Unlock()
+	if leader, ok := s.coalescing.inflight[k]; ok {
+		leader.group.followers = append(leader.group.followers, ci)
+		s.coalesced.Add(1)
+		return true
+	}
+	ci.group = &coalesceGroup{s: s, key: k}
+	s.coalescing.inflight[k] = ci
+	return false
+}
+
+// release 注销 leader 并取出其 followers，此后到达的相同请求将成为新的 leader。
+func (g *coalesceGroup) release() []*CallInfo {
+	c := &g.s.coalescing
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if leader, ok := c.inflight[g.key]; ok && leader.group == g {
+		delete(c.inflight, g.key)
+	}
+	followers := g.followers
+	g.followers = nil
+	return followers
+}
+
+// shared 判断是否有其他调用方在等待 leader 的结果。
+func (g *coalesceGroup) shared() bool {
+	if g == nil {
+		return false
+	}
+	g.s.coalescing.mu.Lock()
+	defer g.s.coalescing.mu.Unlock()
+	return len(g.followers) > 0
+}
+
+// finish 将 leader 的结果分发给全部 followers，每个调用方收到独立的 RetInfo。
+func (g *coalesceGroup) finish(ri *RetInfo) {
+	var ack any
+	var err error
+	if ri != nil {
+		ack, err = ri.Ack, ri.Err
+	}
+	for _, ci := range g.release() {
+		_ = ci.ret(&RetInfo{Ack: ack, Err: err})
+	}
+}
@@ -0,0 +1,71 @@
+package chanrpc
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type queryReq struct{ Key string }
+
+// drain 在当前 goroutine 中执行 s 积压的全部调用。
+func drain(s *Server) {
+	for ci := s.Poll(); ci != nil; ci = s.Poll() {
+		s.Exec(ci)
+	}
+}
+
+func TestCoalescingFanOut(t *testing.T) {
+	const n = 20
+	s := NewServer(n)
+	executed := map[string]int{}
+	_ = s.Register(&queryReq{}, func(ci *CallInfo) *RetInfo {
+		key := ci.Request.(*queryReq).Key
+		executed[key]++
+		return &RetInfo{Ack: "result " + key}
+	})
+	if err := s.EnableCoalescing(&queryReq{}, func(req any) string { return req.(*queryReq).Key }); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(n)
+
+	// 全部调用在事件循环处理前并发到达
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			key := fmt.Sprint(i % 2)
+			err := c.AsyncCall(s, &queryReq{Key: key}, func(ri *RetInfo) {
+				if ri.Err != nil || ri.Ack != "result "+key {
+					t.Errorf("key %s ret = %v %v", key, ri.Ack, ri.Err)
+				}
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	if s.Len() != 2 {
+		t.Errorf("queued = %d, want 2", s.Len())
+	}
+	drain(s)
+
+	for range n {
+		c.AsyncCallback(<-c.ChanAsyncRet)
+	}
+	if executed["0"] != 1 || executed["1"] != 1 {
+		t.Errorf("executed = %v, want once per key", executed)
+	}
+	if got := s.Metrics().Coalesced; got != n-2 {
+		t.Errorf("coalesced = %d, want %d", got, n-2)
+	}
+
+	// 回包后相同请求重新执行
+	ri := make(chan *RetInfo, 1)
+	_ = c.AsyncCall(s, &queryReq{Key: "0"}, func(r *RetInfo) { ri <- r })
+	drain(s)
+	c.AsyncCallback(<-c.ChanAsyncRet)
+	if (<-ri).Err != nil || executed["0"] != 2 {
+		t.Errorf("executed after reply = %v, want key 0 twice", executed)
+	}
+}
@@ -0,0 +1,132 @@
+package chanrpc
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DeadlockError 同步调用将形成循环等待时返回的错误，Chain 为从发起方出发并回到发起方的等待链。
+//
+// 通过 errors.Is(err, ErrDeadlock) 判断，或 errors.As 取出完整的调用链。
+type DeadlockError struct {
+	Chain []string // 等待链上的服务端名称，如 ["A", "B", "A"] 表示 A 等待 B、B 等待 A
+}
+
+// Error 实现 error 接口。
+func (e *DeadlockError) Error() string {
+	return "chanrpc: sync call deadlock: " + strings.Join(e.Chain, " -> ")
+}
+
+// Is 使 errors.Is(err, ErrDeadlock) 成立。
+func (e *DeadlockError) Is(target error) bool {
+	return target == ErrDeadlock
+}
+
+// waitGraph 全局同步调用等待图，记录哪个模块（以其 Server 标识）正阻塞在对哪个模块的同步调用上。
+//
+// 边 from → to 表示 from 的事件循环正在等待 to 处理其同步调用。新增边前检查 to 是否已（直接或间接）
+// 在等待 from，若是则新增的边会闭合一个环，所有环上的事件循环都将永久阻塞，因此直接拒绝本次调用。
+// 同一对模块之间可能存在多个并发的同步调用（如非事件循环 goroutine 共用 Client），边以计数表示。
+type waitGraph struct {
+	mu    sync.Mutex
+	edges map[*Server]map[*Server]int
+}
+
+// waits 进程内所有 Server 共享的等待图，跨 App 的调用同样需要检测。
+var waits = &waitGraph{edges: make(map[*Server]map[*Server]int)}
+
+// acquire 在 from 阻塞等待 to 之前登记等待关系，若将形成循环等待则返回 *DeadlockError 且不登记。
+func (g *waitGraph) acquire(from, to *Server) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if path := g.path(to, from); path != nil {
+		chain := make([]string, 0, len(path)+1)
+		chain = append(chain, from.Name())
+		for _, s := range path {
+			chain = append(chain, s.Name())
+		}
+		return &DeadlockError{Chain: chain}
+	}
+
+	targets, ok := g.edges[from]
+	if !ok {
+		targets = make(map[*Server]int)
+		g.edges[from] = targets
+	}
+	targets[to]++
+	return nil
+}
+
+// release 在同步调用返回后移除 acquire 登记的等待关系。
+func (g *waitGraph) release(from, to *Server) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	targets := g.edges[from]
+	if targets[to]--; targets[to] <= 0 {
+		delete(targets, to)
+	}
+	if len(targets) == 0 {
+		delete(g.edges, from)
+	}
+}
+
+// path 深度优先查找从 from 到 to 的等待路径，返回路径上的节点（含两端），不存在时返回 nil。
+//
+// 调用方须持有 g.mu。from == to 时返回单节点路径，对应模块同步调用自身的情况。
+func (g *waitGraph) path(from, to *Server) []*Server {
+	visited := make(map[*Server]bool)
+	var dfs func(cur *Server) []*Server
+	dfs = func(cur *Server) []*Server {
+		if cur == to {
+			return []*Server{cur}
+		}
+		visited[cur] = true
+		for next := range g.edges[cur] {
+			if visited[next] {
+				continue
+			}
+			if rest := dfs(next); rest != nil {
+				return append([]*Server{cur}, rest...)
+			}
+		}
+		return nil
+	}
+	return dfs(from)
+}
+
+// SetOwner 设置客户端所属模块的 Server，用于同步调用的死锁检测。
+//
+// 设置后，经由此客户端发起的 Call/CallContext 会登记"所属模块正在等待目标模块"，
+// 若目标模块已直接或间接在等待所属模块，调用立即失败并返回 *DeadlockError，而不是让双方事件循环永久阻塞。
+// Skeleton 会自动设置为自身的 Server；未设置时不做检测。
+// 检测假定经由此客户端的同步调用都发生在所属模块的事件循环中，在其他 goroutine 中发起时可能误报。
+func (c *Client) SetOwner(s *Server) {
+	c.owner = s
+}
+
+// enterSyncCall 登记同步调用的等待关系，返回的函数用于调用结束后解除登记。
+func (c *Client) enterSyncCall(s *Server) (func(), error) {
+	if c.owner == nil {
+		return func() {}, nil
+	}
+	if err := waits.acquire(c.owner, s); err != nil {
+		return nil, err
+	}
+	return func() { waits.release(c.owner, s) }, nil
+}
+
+// SetName 设置服务端名称，用于死锁调用链等诊断信息，Skeleton 会自动设置为模块名称。
+func (s *Server) SetName(name string) {
+	s.name = name
+}
+
+// Name 返回服务端名称，未设置时返回其地址。
+func (s *Server) Name() string {
+	if s.name == "" {
+		return fmt.Sprintf("%p", s)
+	}
+	return s.name
+}
@@ -0,0 +1,62 @@
+package chanrpc
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+type pingReq struct{}
+
+type pongReq struct{}
+
+func TestDeadlockCycle(t *testing.T) {
+	a, b := NewServer(16), NewServer(16)
+	a.SetName("a")
+	b.SetName("b")
+	ca, cb := NewClient(16), NewClient(16)
+	ca.SetOwner(a)
+	cb.SetOwner(b)
+
+	// a 同步调用 b，b 在处理中再同步调用 a，形成 a → b → a 的循环等待
+	_ = a.Register(&pingReq{}, func(ci *CallInfo) *RetInfo {
+		return ca.Call(b, &pongReq{})
+	})
+	_ = b.Register(&pongReq{}, func(ci *CallInfo) *RetInfo {
+		return cb.Call(a, &pingReq{})
+	})
+	testServer(t, a, ca)
+	testServer(t, b, cb)
+
+	ri := NewClient(1).Call(a, &pingReq{})
+	var de *DeadlockError
+	if !errors.As(ri.Err, &de) || !errors.Is(ri.Err, ErrDeadlock) {
+		t.Fatalf("call err = %v, want *DeadlockError", ri.Err)
+	}
+	if want := []string{"b", "a", "b"}; !slices.Equal(de.Chain, want) {
+		t.Errorf("chain = %v, want %v", de.Chain, want)
+	}
+
+	// 调用结束后等待关系全部解除
+	waits.mu.Lock()
+	n := len(waits.edges)
+	waits.mu.Unlock()
+	if n != 0 {
+		t.Errorf("wait graph has %d nodes after calls returned, want 0", n)
+	}
+}
+
+func TestDeadlockSelfCall(t *testing.T) {
+	a := NewServer(16)
+	a.SetName("self")
+	ca := NewClient(16)
+	ca.SetOwner(a)
+	_ = a.Register(&pingReq{}, func(ci *CallInfo) *RetInfo {
+		return ca.Call(a, &pingReq{})
+	})
+	testServer(t, a, ca)
+
+	if ri := NewClient(1).Call(a, &pingReq{}); !errors.Is(ri.Err, ErrDeadlock) {
+		t.Fatalf("self call err = %v, want %v", ri.Err, ErrDeadlock)
+	}
+}
@@ -1,6 +1,7 @@
 package chanrpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"runtime/debug"
@@ -24,12 +25,21 @@ var (
 	ErrRegisterHandlerNil = errors.New("chanrpc: register handler cannot be nil")
 	ErrCallChannelNil     = errors.New("chanrpc: call channel is nil")
 	ErrCallInfoNil        = errors.New("chanrpc: call CallInfo is nil")
+	ErrTypeMismatch       = errors.New("chanrpc: type mismatch")
+	ErrAlreadyReplied     = errors.New("chanrpc: already replied")
+	ErrReplyTimeout       = errors.New("chanrpc: deferred reply timeout")
+	ErrDeadlock           = errors.New("chanrpc: sync call deadlock")
+	ErrMessageIDCollision = errors.New("chanrpc: message id collision")
+	ErrQueueFull          = errors.New("chanrpc: queue full")
+	ErrCallDropped        = errors.New("chanrpc: call dropped by backpressure")
+	ErrUnknownMessage     = errors.New("chanrpc: unknown message id")
 )
 
 // Handler RPC 消息处理函数类型，接收调用信息并返回结果信息。
 //
 // 处理函数在 Server 所在模块的 goroutine 中串行执行，天然保证对模块内部状态的无锁访问。
 // 若处理逻辑无需向调用方回包（如 Cast），可返回 nil。
+// 需要等待其他模块结果后再回包时，调用 CallInfo.Defer 切换为延迟响应模式，此时返回值被忽略。
 type Handler func(ci *CallInfo) (ri *RetInfo)
 
 // Callback 异步调用的回调函数类型，在 Client 所在模块的 goroutine 中执行。
@@ -45,12 +55,26 @@ type Callback func(ri *RetInfo)
 //
 // hasRet 通过 atomic.Bool 的 CAS 语义实现防重复响应：
 // 正常路径和 panic 恢复路径都会尝试响应，CAS 保证只有第一次成功。
+//
+// ctx 仅在 CallContext/AsyncCallContext/CastContext 发起的调用中非 nil，其截止时间约束整个往返过程；
+// delivered 保证服务端响应与调用方超时两者只有一个送达调用方。
 type CallInfo struct {
-	Request   any           `json:"request"` // 请求数据，业务 handler 的输入
-	messageID uint32        // 消息类型 ID，用于路由到对应的 Handler
-	chanRet   chan *RetInfo // 响应通道：同步调用时为独立 channel，异步调用时为 Client.ChanAsyncRet
-	callback  Callback      // 异步调用的回调函数，同步调用时为 nil
-	hasRet    atomic.Bool   // 防重复响应标志，通过 CAS 操作保证并发安全
+	Request   any                 `json:"request"` // 请求数据，业务 handler 的输入
+	messageID uint32              // 消息类型 ID，用于路由到对应的 Handler
+	chanRet   chan *RetInfo       // 响应通道：同步调用时为独立 channel，异步调用时为 Client.ChanAsyncRet
+	callback  Callback            // 异步调用的回调函数，同步调用时为 nil
+	hasRet    atomic.Bool         // 防重复响应标志，通过 CAS 操作保证并发安全
+	ctx       context.Context     // 调用方上下文，nil 表示调用方未设置截止时间
+	delivered atomic.Bool         // 结果是否已送达调用方（服务端响应或调用方超时），仅 ctx 非 nil 时使用
+	stopAfter func() bool         // 停止 AsyncCallContext 注册的超时回调，在投递前赋值，之后只读
+	deferred  bool                // 处理函数是否已通过 Defer 切换为延迟响应，仅在服务端事件循环中读写
+	reply     *Reply              // Defer 创建的延迟响应句柄，仅在服务端事件循环中读写
+	onResult  []func(ri *RetInfo) // 客户端拦截器注册的结果回调，在投递前注册，之后只读
+	span      SpanContext         // 本次调用的追踪标识，在投递前分配，之后只读
+	parentID  string              // 父调用的 SpanID，调用链的第一次调用为空
+	started   time.Time           // 服务端开始处理的时间，仅设置了 SpanExporter 时记录
+	server    string              // 处理本次调用的 Server 名称，与 started 一同记录
+	group     *coalesceGroup      // 作为合并请求的 leader 时非 nil，回包时将结果分发给合并进来的调用
 }
 
 // ret 向调用方发送响应结果，通过 hasRet CAS 防止同一次调用被重复响应。
@@ -67,10 +91,26 @@ func (ci *CallInfo) ret(ri *RetInfo) (err error) {
 
 	// CompareAndSwap(false → true) 保证只有第一次 ret 调用成功，后续调用均被忽略
 	if !ci.hasRet.CompareAndSwap(false, true) {
-		xlog.Warnf("chanrpc message_id %d can not ret twice, %s", ci.MessageID(), string(debug.Stack()))
-		return
+		ci.Logger().Warnf("chanrpc message_id %d can not ret twice, %s", ci.MessageID(), string(debug.Stack()))
+		return ErrAlreadyReplied
+	}
+	ci.endSpan(ri)
+	if ci.group != nil {
+		ci.group.finish(ri)
+	}
+
+	// 调用方已因超时或取消收到错误结果，响应直接丢弃
+	if !ci.claimDelivery() {
+		return nil
+	}
+	if ci.stopAfter != nil {
+		ci.stopAfter()
 	}
+	return ci.deliver(ri)
+}
 
+// deliver 将结果写入调用方的响应通道，调用方须已通过 hasRet/claimDelivery 保证只送达一次。
+func (ci *CallInfo) deliver(ri *RetInfo) (err error) {
 	// 捕获向已关闭 channel 发送时可能触发的 panic（Server.Close 后仍有调用在处理中）
 	defer func() {
 		if r := recover(); r != nil {
@@ -85,6 +125,8 @@ func (ci *CallInfo) ret(ri *RetInfo) (err error) {
 
 	// 将回调函数附加到响应对象，由 Client.AsyncCallback 在调用方 goroutine 中执行
 	ri.callback = ci.callback
+	ri.onResult = ci.onResult
+	ri.origin = SpanContext{TraceID: ci.span.TraceID, SpanID: ci.parentID}
 
 	// 带超时的非阻塞发送，防止调用方已超时离开导致 goroutine 永久阻塞
 	timer := time.NewTimer(5 * time.Second)
@@ -102,11 +144,54 @@ func (ci *CallInfo) MessageID() uint32 {
 	return ci.messageID
 }
 
+// IsCast 判断本次调用是否为 Cast（单向投递），Cast 调用不回包。
+func (ci *CallInfo) IsCast() bool {
+	return ci.chanRet == nil
+}
+
+// Context 返回调用方的上下文，调用方未通过 CallContext/AsyncCallContext 发起调用时以 context.Background() 为基础。
+//
+// 返回的上下文携带本次调用的追踪标识，处理函数以它发起的 CallContext/AsyncCallContext 自动成为本次调用的子调用。
+func (ci *CallInfo) Context() context.Context {
+	ctx := ci.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !ci.span.IsValid() {
+		return ctx
+	}
+	return ContextWithSpan(ctx, ci.span)
+}
+
+// Deadline 返回调用方的截止时间，未设置截止时间时 ok 为 false。
+//
+// 处理函数可据此估算剩余时间，跳过来不及完成的耗时操作。
+func (ci *CallInfo) Deadline() (deadline time.Time, ok bool) {
+	if ci.ctx == nil {
+		return time.Time{}, false
+	}
+	return ci.ctx.Deadline()
+}
+
+// Abandoned 判断调用方是否已放弃本次调用（超时或取消），已放弃的调用无需再处理。
+func (ci *CallInfo) Abandoned() bool {
+	return ci.ctx != nil && ci.ctx.Err() != nil
+}
+
+// claimDelivery 抢占向调用方送达结果的权利，返回 false 表示结果已由另一方送达。
+//
+// 未携带 ctx 的调用只有服务端一方会送达结果，始终返回 true。
+func (ci *CallInfo) claimDelivery() bool {
+	return ci.ctx == nil || ci.delivered.CompareAndSwap(false, true)
+}
+
 // RetInfo 封装 RPC 调用的响应数据，同时作为异步回调的上下文载体。
 type RetInfo struct {
-	Ack      any      `json:"Ack"` // 响应业务数据，作为 Callback 的输入参数
-	Err      error    `json:"Err"` // 调用或处理过程中发生的错误
-	callback Callback // 异步回调函数引用，由 Client.AsyncCallback 触发执行
+	Ack      any                 `json:"Ack"` // 响应业务数据，作为 Callback 的输入参数
+	Err      error               `json:"Err"` // 调用或处理过程中发生的错误
+	callback Callback            // 异步回调函数引用，由 Client.AsyncCallback 触发执行
+	onResult []func(ri *RetInfo) // 客户端拦截器注册的结果回调，由 Client.AsyncCallback 在 callback 之前执行
+	origin   SpanContext         // 发起调用时所处的调用，Client.AsyncCallback 执行回调期间以其作为嵌套调用的父调用
 }
 
 // MessageID 返回响应数据（Ack）的类型 ID，用于异步回调场景下的消息路由。
@@ -0,0 +1,114 @@
+package chanrpc
+
+import (
+	"runtime/debug"
+	"time"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+// ServerInterceptor 服务端拦截器，包裹 Server 上每一次 Handler 执行。
+//
+// 拦截器通过 ci.MessageID() 和 ci.Request 获取消息信息，调用 next 执行后续拦截器和 Handler 并得到结果，
+// 可在前后附加计时、鉴权、日志等横切逻辑；不调用 next 直接返回 &RetInfo{Err: ...} 即拒绝本次调用。
+// Handler 通过 Defer 切换为延迟响应时 next 返回 nil，可通过 ci.Deferred() 区分；Cast 调用的结果同样被丢弃。
+// 拦截器与 Handler 一样在服务端事件循环中串行执行，其中的 panic 与 Handler 的 panic 一样被捕获并回包错误。
+type ServerInterceptor func(ci *CallInfo, next Handler) *RetInfo
+
+// Invoker 将调用投递到服务端，返回投递错误（不包括服务端的处理错误）。
+type Invoker func(ci *CallInfo) error
+
+// ClientInterceptor 客户端拦截器，包裹 Client 发出的每一次调用（Call、AsyncCall、Cast 及其 Context 版本）。
+//
+// 拦截器在发起调用的 goroutine 中执行，调用 next 完成投递，返回错误即拒绝本次调用（不调用 next 时调用不会发出）。
+// 服务端结果异步到达，需要观察结果（如计时、追踪）时通过 ci.OnResult 注册结果回调。
+type ClientInterceptor func(ci *CallInfo, next Invoker) error
+
+// AddInterceptor 追加服务端拦截器，先添加的拦截器位于外层，须在开始服务前调用（通常在 OnInit 中）。
+func (s *Server) AddInterceptor(interceptors ...ServerInterceptor) {
+	for _, interceptor := range interceptors {
+		if interceptor != nil {
+			s.interceptors = append(s.interceptors, interceptor)
+		}
+	}
+}
+
+// handle 经过拦截器链执行 handler。
+func (s *Server) handle(ci *CallInfo, handler Handler) *RetInfo {
+	for i := len(s.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := s.interceptors[i], handler
+		handler = func(ci *CallInfo) *RetInfo {
+			return interceptor(ci, next)
+		}
+	}
+	return handler(ci)
+}
+
+// AddInterceptor 追加客户端拦截器，先添加的拦截器位于外层，须在发起调用前调用（通常在 OnInit 中）。
+func (c *Client) AddInterceptor(interceptors ...ClientInterceptor) {
+	for _, interceptor := range interceptors {
+		if interceptor != nil {
+			c.interceptors = append(c.interceptors, interceptor)
+		}
+	}
+}
+
+// invoke 分配追踪标识后经过拦截器链执行 send，send 负责实际投递及异步计数等记账，无论拦截器如何返回都保持计数正确。
+func (c *Client) invoke(ci *CallInfo, send Invoker) error {
+	c.startSpan(ci)
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := c.interceptors[i], send
+		send = func(ci *CallInfo) error {
+			return interceptor(ci, next)
+		}
+	}
+	return send(ci)
+}
+
+// OnResult 注册调用结果回调，供客户端拦截器观察本次调用的结果，须在投递前（即调用 next 之前）注册。
+//
+// 同步调用在调用方 goroutine 返回前执行，异步调用在调用方事件循环执行 callback 之前执行；
+// 投递失败或 Cast 调用不会执行。回调中的 panic 会被捕获并记录日志。
+func (ci *CallInfo) OnResult(f func(ri *RetInfo)) {
+	if f != nil {
+		ci.onResult = append(ci.onResult, f)
+	}
+}
+
+// Deferred 返回处理函数是否已通过 Defer 切换为延迟响应，只能在服务端事件循环中调用。
+func (ci *CallInfo) Deferred() bool {
+	return ci.deferred
+}
+
+// notifyResult 依次执行结果回调，并捕获其中的 panic，避免影响调用方。
+func notifyResult(hooks []func(ri *RetInfo), ri *RetInfo) {
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					xlog.Errorf("chanrpc result hook panic recovered, panic %v\n%s", r, string(debug.Stack()))
+				}
+			}()
+			hook(ri)
+		}()
+	}
+}
+
+// LogSlowHandlers 返回记录慢 Handler 的服务端拦截器，单次执行（含内层拦截器）耗时达到 threshold 时打印警告日志。
+func LogSlowHandlers(threshold time.Duration) ServerInterceptor {
+	return reportSlowHandlers(threshold, func(ci *CallInfo, elapsed time.Duration) {
+		ci.Logger().Warnf("chanrpc message_id %d slow handler, type %T, elapsed %s", ci.MessageID(), ci.Request, elapsed)
+	})
+}
+
+// reportSlowHandlers 返回在单次执行耗时达到 threshold 时调用 report 的服务端拦截器。
+func reportSlowHandlers(threshold time.Duration, report func(ci *CallInfo, elapsed time.Duration)) ServerInterceptor {
+	return func(ci *CallInfo, next Handler) *RetInfo {
+		start := time.Now()
+		ri := next(ci)
+		if elapsed := time.Since(start); elapsed >= threshold {
+			report(ci, elapsed)
+		}
+		return ri
+	}
+}
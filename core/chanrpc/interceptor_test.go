@@ -0,0 +1,229 @@
+package chanrpc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type interceptReq struct{ N int }
+
+type deniedReq struct{}
+
+type deferredReq struct{}
+
+var errDenied = errors.New("denied")
+
+// callRecorder 并发安全地记录拦截器与处理函数的执行顺序。
+type callRecorder struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (r *callRecorder) add(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+// take 返回并清空已记录的步骤。
+func (r *callRecorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	steps := r.steps
+	r.steps = nil
+	return steps
+}
+
+// tagInterceptor 返回在 next 前后记录 name 的服务端拦截器。
+func tagInterceptor(rec *callRecorder, name string) ServerInterceptor {
+	return func(ci *CallInfo, next Handler) *RetInfo {
+		rec.add(name + ">")
+		ri := next(ci)
+		rec.add("<" + name)
+		return ri
+	}
+}
+
+func TestServerInterceptor(t *testing.T) {
+	rec := &callRecorder{}
+	deferredSeen := make(chan bool, 1)
+	replies := make(chan *Reply, 1)
+
+	s := NewServer(16)
+	_ = s.Register(&interceptReq{}, func(ci *CallInfo) *RetInfo {
+		rec.add("handler")
+		return &RetInfo{Ack: ci.Request.(*interceptReq).N}
+	})
+	_ = s.Register(&deniedReq{}, func(ci *CallInfo) *RetInfo {
+		rec.add("handler")
+		return &RetInfo{}
+	})
+	_ = s.Register(&deferredReq{}, func(ci *CallInfo) *RetInfo {
+		replies <- ci.Defer(time.Minute)
+		return nil
+	})
+	_ = s.Register(&panicReq{}, func(ci *CallInfo) *RetInfo {
+		rec.add("handler")
+		return &RetInfo{}
+	})
+	s.AddInterceptor(
+		tagInterceptor(rec, "outer"),
+		func(ci *CallInfo, next Handler) *RetInfo {
+			switch ci.Request.(type) {
+			case *deniedReq:
+				// 不调用 next，直接拒绝
+				return &RetInfo{Err: errDenied}
+			case *deferredReq:
+				ri := next(ci)
+				deferredSeen <- ci.Deferred() && ri == nil
+				return ri
+			case *panicReq:
+				panic("interceptor boom")
+			}
+			return next(ci)
+		},
+		tagInterceptor(rec, "inner"),
+	)
+	testServer(t, s, nil)
+	c := NewClient(16)
+
+	t.Run("order", func(t *testing.T) {
+		if ri := c.Call(s, &interceptReq{N: 7}); ri.Err != nil || ri.Ack != 7 {
+			t.Fatalf("call = %v %v, want 7", ri.Ack, ri.Err)
+		}
+		want := []string{"outer>", "inner>", "handler", "<inner", "<outer"}
+		if got := rec.take(); !slices.Equal(got, want) {
+			t.Errorf("steps = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		if ri := c.Call(s, &deniedReq{}); !errors.Is(ri.Err, errDenied) {
+			t.Fatalf("call err = %v, want %v", ri.Err, errDenied)
+		}
+		want := []string{"outer>", "<outer"}
+		if got := rec.take(); !slices.Equal(got, want) {
+			t.Errorf("steps = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("deferred", func(t *testing.T) {
+		go func() {
+			reply := <-replies
+			if !<-deferredSeen {
+				t.Error("interceptor did not observe deferred reply")
+			}
+			_ = reply.Done("late", nil)
+		}()
+		if ri := c.Call(s, &deferredReq{}); ri.Err != nil || ri.Ack != "late" {
+			t.Fatalf("call = %v %v, want late", ri.Ack, ri.Err)
+		}
+		rec.take()
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		before := s.Metrics().Panics
+		if ri := c.Call(s, &panicReq{}); ri.Err == nil || !strings.Contains(ri.Err.Error(), "interceptor boom") {
+			t.Fatalf("call err = %v, want panic error", ri.Err)
+		}
+		if got := s.Metrics().Panics; got != before+1 {
+			t.Errorf("panics = %d, want %d", got, before+1)
+		}
+		// 外层拦截器因 panic 未能执行 next 之后的部分，处理函数未执行
+		want := []string{"outer>"}
+		if got := rec.take(); !slices.Equal(got, want) {
+			t.Errorf("steps = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestClientOnResult(t *testing.T) {
+	rec := &callRecorder{}
+	handled := make(chan struct{}, 1)
+
+	s := NewServer(16)
+	_ = s.Register(&interceptReq{}, func(ci *CallInfo) *RetInfo {
+		if ci.IsCast() {
+			handled <- struct{}{}
+		}
+		return &RetInfo{Ack: ci.Request.(*interceptReq).N}
+	})
+	c := NewClient(16)
+	c.AddInterceptor(func(ci *CallInfo, next Invoker) error {
+		ci.OnResult(func(ri *RetInfo) {
+			rec.add(fmt.Sprintf("result %v %v", ri.Ack, ri.Err))
+		})
+		return next(ci)
+	})
+	testServer(t, s, c)
+
+	if ri := c.Call(s, &interceptReq{N: 1}); ri.Err != nil {
+		t.Fatal(ri.Err)
+	}
+	if got, want := rec.take(), []string{"result 1 <nil>"}; !slices.Equal(got, want) {
+		t.Errorf("call results = %v, want %v", got, want)
+	}
+
+	done := make(chan struct{})
+	err := c.AsyncCall(s, &interceptReq{N: 2}, func(ri *RetInfo) {
+		rec.add("callback")
+		close(done)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if got, want := rec.take(), []string{"result 2 <nil>", "callback"}; !slices.Equal(got, want) {
+		t.Errorf("async call results = %v, want %v", got, want)
+	}
+
+	c.Cast(s, &interceptReq{N: 3})
+	<-handled
+	// 同步调用在 Cast 之后处理，返回时 Cast 已处理完毕
+	if ri := c.Call(s, &interceptReq{N: 4}); ri.Err != nil {
+		t.Fatal(ri.Err)
+	}
+	if got, want := rec.take(), []string{"result 4 <nil>"}; !slices.Equal(got, want) {
+		t.Errorf("cast results = %v, want %v", got, want)
+	}
+}
+
+func TestLogSlowHandlers(t *testing.T) {
+	var slow []time.Duration
+	s := NewServer(16)
+	_ = s.Register(&slowReq{}, func(ci *CallInfo) *RetInfo {
+		time.Sleep(ci.Request.(*slowReq).Delay)
+		return &RetInfo{Ack: "ok"}
+	})
+	s.AddInterceptor(reportSlowHandlers(20*time.Millisecond, func(ci *CallInfo, elapsed time.Duration) {
+		slow = append(slow, elapsed)
+	}))
+	testServer(t, s, nil)
+	c := NewClient(16)
+
+	if ri := c.Call(s, &slowReq{}); ri.Err != nil || ri.Ack != "ok" {
+		t.Fatalf("fast call = %v %v, want ok", ri.Ack, ri.Err)
+	}
+	if ri := c.Call(s, &slowReq{Delay: 30 * time.Millisecond}); ri.Err != nil || ri.Ack != "ok" {
+		t.Fatalf("slow call = %v %v, want ok", ri.Ack, ri.Err)
+	}
+	if len(slow) != 1 || slow[0] < 20*time.Millisecond {
+		t.Errorf("reported = %v, want one elapsed >= 20ms", slow)
+	}
+
+	// 公开的 LogSlowHandlers 只记录日志，不改变处理结果
+	s2 := NewServer(16)
+	_ = s2.Register(&slowReq{}, func(ci *CallInfo) *RetInfo {
+		return &RetInfo{Ack: "ok"}
+	})
+	s2.AddInterceptor(LogSlowHandlers(0))
+	testServer(t, s2, nil)
+	if ri := c.Call(s2, &slowReq{}); ri.Err != nil || ri.Ack != "ok" {
+		t.Fatalf("logged call = %v %v, want ok", ri.Ack, ri.Err)
+	}
+}
@@ -0,0 +1,137 @@
+package chanrpc
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBuckets Handler 执行耗时直方图的桶上界，覆盖 100 微秒到 5 秒。
+//
+// 模块事件循环是串行的，单个 Handler 耗时超过毫秒级即会拖慢整个模块，
+// 因此桶在亚毫秒到百毫秒区间分布较密，便于观察长尾耗时。
+var LatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// handlerStats 单个消息类型 Handler 的执行统计。
+//
+// 由模块事件循环写入、由监控 goroutine 读取，全部字段采用原子操作，无需加锁。
+// 各字段之间不保证快照一致性，对监控场景而言可以接受。
+type handlerStats struct {
+	message string          // 消息类型名称，用于指标标签
+	count   atomic.Uint64   // 执行次数
+	sum     atomic.Int64    // 累计执行耗时（纳秒）
+	buckets []atomic.Uint64 // 各耗时桶的非累计计数，与 LatencyBuckets 一一对应
+	panics  atomic.Uint64   // 执行过程中 panic 的次数
+}
+
+// newHandlerStats 创建消息类型对应的 Handler 执行统计。
+func newHandlerStats(message string) *handlerStats {
+	return &handlerStats{
+		message: message,
+		buckets: make([]atomic.Uint64, len(LatencyBuckets)),
+	}
+}
+
+// observe 记录一次 Handler 执行耗时，超出最大桶上界的耗时仅计入 count 和 sum。
+func (hs *handlerStats) observe(d time.Duration) {
+	hs.count.Add(1)
+	hs.sum.Add(int64(d))
+	if i, _ := slices.BinarySearch(LatencyBuckets, d); i < len(hs.buckets) {
+		hs.buckets[i].Add(1)
+	}
+}
+
+// HandlerMetrics 单个消息类型 Handler 的执行指标快照。
+type HandlerMetrics struct {
+	MessageID uint32        `json:"message_id"` // 消息 ID
+	Message   string        `json:"message"`    // 消息类型名称
+	Count     uint64        `json:"count"`      // 执行次数
+	Sum       time.Duration `json:"sum"`        // 累计执行耗时
+	Buckets   []uint64      `json:"buckets"`    // 累计桶计数：Buckets[i] 为耗时不超过 LatencyBuckets[i] 的执行次数
+	Panics    uint64        `json:"panics"`     // panic 次数
+}
+
+// ServerMetrics Server 的指标快照。
+type ServerMetrics struct {
+	QueueLen     int                 `json:"queue_len"`    // 全部优先级通道当前积压的调用数量
+	QueueCap     int                 `json:"queue_cap"`    // 全部优先级通道的容量之和
+	Lanes        []LaneMetrics       `json:"lanes"`        // 各优先级通道的积压，按优先级从高到低
+	Backpressure BackpressureMetrics `json:"backpressure"` // 背压策略与各背压事件的累计次数
+	Panics       uint64              `json:"panics"`       // 所有 Handler 的 panic 总次数
+	Abandoned    uint64              `json:"abandoned"`    // 出队时调用方已超时或取消而被丢弃的调用次数
+	Coalesced    uint64              `json:"coalesced"`    // 合并到相同在途请求而未入队的调用次数
+	Batches      uint64              `json:"batches"`      // 批量处理函数的执行次数
+	Handlers     []HandlerMetrics    `json:"handlers"`     // 各消息类型 Handler 的执行指标，按消息 ID 升序
+}
+
+// Metrics 返回 Server 的指标快照。
+//
+// 各项计数均为原子读取，但 Handler 执行统计表与路由表一样未加锁，
+// 仅在全部 Register 完成（通常在 OnInit 中）之后才可在任意 goroutine 中安全调用；
+// 模块开始服务后继续注册消息会与监控 goroutine 的读取产生数据竞争。
+func (s *Server) Metrics() ServerMetrics {
+	m := ServerMetrics{
+		Panics:       s.panics.Load(),
+		Abandoned:    s.abandoned.Load(),
+		Coalesced:    s.coalesced.Load(),
+		Batches:      s.batches.Load(),
+		Lanes:        make([]LaneMetrics, 0, laneCount),
+		Backpressure: s.backpressureMetrics(),
+		Handlers:     make([]HandlerMetrics, 0, len(s.stats)),
+	}
+	for p, lane := range s.lanes {
+		lm := LaneMetrics{Priority: Priority(p).String(), Len: len(lane), Cap: cap(lane), Overflow: int(s.overflow[p].n.Load())}
+		m.QueueLen += lm.Len + lm.Overflow
+		m.QueueCap += lm.Cap
+		m.Lanes = append(m.Lanes, lm)
+	}
+	for id, hs := range s.stats {
+		hm := HandlerMetrics{
+			MessageID: id,
+			Message:   hs.message,
+			Count:     hs.count.Load(),
+			Sum:       time.Duration(hs.sum.Load()),
+			Buckets:   make([]uint64, len(hs.buckets)),
+			Panics:    hs.panics.Load(),
+		}
+		var cumulative uint64
+		for i := range hs.buckets {
+			cumulative += hs.buckets[i].Load()
+			hm.Buckets[i] = cumulative
+		}
+		m.Handlers = append(m.Handlers, hm)
+	}
+	slices.SortFunc(m.Handlers, func(a, b HandlerMetrics) int {
+		return int(int64(a.MessageID) - int64(b.MessageID))
+	})
+	return m
+}
+
+// ClientMetrics Client 的指标快照。
+type ClientMetrics struct {
+	AsyncRetLen    int    `json:"async_ret_len"`   // ChanAsyncRet 当前积压的异步结果数量
+	AsyncRetCap    int    `json:"async_ret_cap"`   // ChanAsyncRet 容量
+	Pending        int64  `json:"pending"`         // 尚未执行回调的异步调用数量
+	CallbackPanics uint64 `json:"callback_panics"` // 异步回调 panic 次数
+}
+
+// Metrics 返回 Client 的指标快照，可在任意 goroutine 中安全调用。
+func (c *Client) Metrics() ClientMetrics {
+	return ClientMetrics{
+		AsyncRetLen:    len(c.ChanAsyncRet),
+		AsyncRetCap:    cap(c.ChanAsyncRet),
+		Pending:        c.pendingAsyncCall.Load(),
+		CallbackPanics: c.callbackPanics.Load(),
+	}
+}
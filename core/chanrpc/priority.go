@@ -0,0 +1,159 @@
+package chanrpc
+
+import (
+	"context"
+	"fmt"
+)
+
+// Priority 调用的优先级，决定调用进入 Server 的哪条队列（通道）。
+type Priority int32
+
+const (
+	// PriorityHigh 高优先级，适合关闭、运维指令等需要越过积压消息尽快处理的调用。
+	PriorityHigh Priority = iota
+	// PriorityNormal 普通优先级（默认），对应 Server.ChanCall。
+	PriorityNormal
+	// PriorityLow 低优先级，适合统计上报、广播通知等允许延后处理的大批量消息。
+	PriorityLow
+
+	laneCount = 3 // 优先级通道数量
+)
+
+// starvationLimit 非空的低优先级通道被连续跳过的次数上限。
+// 达到上限后即使更高优先级通道仍有积压，也先处理一条该通道的调用，防止高优先级洪峰导致低优先级调用饿死；
+// 取 32 使高优先级在持续积压时仍占据约 97% 的处理机会。
+const starvationLimit = 32
+
+// String 返回优先级的可读名称，用于日志和指标标签。
+func (p Priority) String() string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+	case PriorityNormal:
+		return "normal"
+	case PriorityLow:
+		return "low"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(p))
+	}
+}
+
+// valid 判断优先级是否为已定义的取值。
+func (p Priority) valid() bool {
+	return p >= PriorityHigh && p < laneCount
+}
+
+// priorityCtxKey 单次调用优先级在 context 中的键。
+type priorityCtxKey struct{}
+
+// WithPriority 返回携带调用优先级的 ctx，传给 CallContext/AsyncCallContext/CastContext 后覆盖消息注册时指定的优先级。
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityCtxKey{}, p)
+}
+
+// priorityFrom 取出 ctx 中的调用优先级，未设置或取值非法时返回 false。
+func priorityFrom(ctx context.Context) (Priority, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	p, ok := ctx.Value(priorityCtxKey{}).(Priority)
+	return p, ok && p.valid()
+}
+
+// RegisterWithPriority 注册消息处理函数，并指定该消息类型的默认优先级。
+//
+// 未通过此方法注册的消息类型默认为 PriorityNormal；单次调用可通过 WithPriority 覆盖。
+func (s *Server) RegisterWithPriority(message any, f Handler, p Priority) error {
+	if !p.valid() {
+		return fmt.Errorf("chanrpc register: invalid priority %v", p)
+	}
+	if err := s.Register(message, f); err != nil {
+		return err
+	}
+	s.priorities[MessageID(message)] = p
+	return nil
+}
+
+// Lane 返回指定优先级的调用通道，供自行实现事件循环的模块使用；Skeleton 通过 Poll 按优先级消费。
+func (s *Server) Lane(p Priority) <-chan *CallInfo {
+	if !p.valid() {
+		return nil
+	}
+	return s.lanes[p]
+}
+
+// priorityFor 确定调用的优先级：ctx 中的优先级优先，其次为消息注册时的优先级，默认为普通优先级。
+func (s *Server) priorityFor(messageID uint32, ctx context.Context) Priority {
+	if p, ok := priorityFrom(ctx); ok {
+		return p
+	}
+	if p, ok := s.priorities[messageID]; ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// Poll 按优先级非阻塞地取出一条待处理调用，所有通道（含溢出队列）均为空时返回 nil。
+//
+// 通常从最高优先级的非空通道取出，同一通道先取通道内的调用，再取溢出队列中更晚入队的调用；某个非空的低优先级通道被连续跳过 starvationLimit 次后，
+// 下一次优先从该通道取出，保证低优先级调用在高优先级持续积压时仍能得到处理。
+// 跳过计数无锁维护，只能在消费 Server 的事件循环 goroutine 中调用。
+func (s *Server) Poll() *CallInfo {
+	pick := -1
+	for p := laneCount - 1; p > 0; p-- {
+		if s.skipped[p] >= starvationLimit && s.pending(p) {
+			pick = p
+			break
+		}
+	}
+	if pick < 0 {
+		for p := range laneCount {
+			if s.pending(p) {
+				pick = p
+				break
+			}
+		}
+	}
+	if pick < 0 {
+		return nil
+	}
+
+	for p := pick + 1; p < laneCount; p++ {
+		if s.pending(p) {
+			s.skipped[p]++
+		}
+	}
+	s.skipped[pick] = 0
+
+	select {
+	case ci, ok := <-s.lanes[pick]:
+		if ok {
+			return ci
+		}
+		return nil
+	default:
+	}
+	return s.overflow[pick].pop()
+}
+
+// pending 判断指定优先级的通道或其溢出队列中是否有待处理调用。
+func (s *Server) pending(p int) bool {
+	return len(s.lanes[p]) > 0 || s.overflow[p].n.Load() > 0
+}
+
+// Len 返回全部优先级通道（含溢出队列）当前积压的调用总数。
+func (s *Server) Len() int {
+	n := 0
+	for p, lane := range s.lanes {
+		n += len(lane) + int(s.overflow[p].n.Load())
+	}
+	return n
+}
+
+// LaneMetrics 单条优先级通道的积压指标。
+type LaneMetrics struct {
+	Priority string `json:"priority"` // 优先级名称
+	Len      int    `json:"len"`      // 当前积压的调用数量
+	Cap      int    `json:"cap"`      // 通道容量
+	Overflow int    `json:"overflow"` // OverflowSpill 策略下溢出队列中的调用数量
+}
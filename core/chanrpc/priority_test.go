@@ -0,0 +1,55 @@
+package chanrpc
+
+import (
+	"context"
+	"testing"
+)
+
+type normalMsg struct{ N int }
+
+type lowMsg struct{ N int }
+
+func TestPollPriorityAndStarvation(t *testing.T) {
+	s := NewServer(64)
+	nop := func(*CallInfo) *RetInfo { return nil }
+	_ = s.Register(&normalMsg{}, nop)
+	_ = s.RegisterWithPriority(&lowMsg{}, nop, PriorityLow)
+	c := NewClient(1)
+
+	for i := range 2 {
+		c.Cast(s, &lowMsg{N: i})
+	}
+	for i := range 2 * starvationLimit {
+		c.Cast(s, &normalMsg{N: i})
+	}
+	// 单次调用通过 ctx 提升优先级
+	c.CastContext(WithPriority(context.Background(), PriorityHigh), s, &lowMsg{N: -1})
+
+	if ci := s.Poll(); ci.Request.(*lowMsg).N != -1 {
+		t.Fatalf("first poll = %+v, want high priority cast", ci.Request)
+	}
+	// 高优先级调用出队时低优先级通道同样计为被跳过一次
+	for i := range starvationLimit - 1 {
+		if ci := s.Poll(); ci.Request.(*normalMsg).N != i {
+			t.Fatalf("poll %d = %+v, want normal %d", i, ci.Request, i)
+		}
+	}
+	// 低优先级通道被连续跳过 starvationLimit 次后优先处理一条
+	if ci := s.Poll(); ci.Request.(*lowMsg).N != 0 {
+		t.Fatalf("poll after starvation = %+v, want low 0", ci.Request)
+	}
+	for i := range starvationLimit {
+		if ci := s.Poll(); ci.Request.(*normalMsg).N != starvationLimit-1+i {
+			t.Fatalf("poll = %+v, want normal %d", ci.Request, starvationLimit-1+i)
+		}
+	}
+	if ci := s.Poll(); ci.Request.(*lowMsg).N != 1 {
+		t.Fatalf("poll = %+v, want low 1", ci.Request)
+	}
+	if ci := s.Poll(); ci.Request.(*normalMsg).N != 2*starvationLimit-1 {
+		t.Fatalf("last poll = %+v, want last normal", ci.Request)
+	}
+	if ci := s.Poll(); ci != nil {
+		t.Fatalf("poll on empty server = %+v, want nil", ci.Request)
+	}
+}
@@ -0,0 +1,170 @@
+package chanrpc
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// MessageEntry 全局消息注册表中的一项。
+type MessageEntry struct {
+	ID       uint32 `json:"id"`       // 消息 ID
+	Type     string `json:"type"`     // 消息类型（指针类型记录为其元素类型）
+	Explicit bool   `json:"explicit"` // 是否通过 IMessageID 显式指定 ID，false 表示由类型名哈希生成
+	Servers  int    `json:"servers"`  // 注册了该消息且尚未关闭的 Server 数量
+}
+
+// messageRegistry 进程内所有 Server 共享的消息 ID → 类型注册表。
+//
+// MessageID 默认为类型名的 32 位哈希，不同类型存在碰撞的可能。各 Server 的路由表相互独立，
+// 若两个碰撞的类型分别注册在不同 Server 上，Server.Register 无法发现，发送方却会被路由到错误的处理函数。
+// 全局注册表在注册时跨 Server 检测碰撞，在发起调用时校验请求类型，将静默的错误路由转化为明确的错误。
+//
+// 读多写少：注册只发生在模块初始化阶段，调用路径只读，因此读取使用 sync.Map 无锁路径，写入由 mu 串行化。
+type messageRegistry struct {
+	mu      sync.Mutex
+	types   sync.Map // uint32 → *messageRecord
+	entries map[uint32]*messageRecord
+}
+
+// messageRecord 单个消息 ID 的注册信息，typ 和 ptr 注册后不再变更。
+type messageRecord struct {
+	typ      reflect.Type
+	ptr      bool // 首次注册时是否以指针形式传入，UnmarshalMessage 据此还原消息形式
+	explicit bool
+	servers  int
+}
+
+// messages 全局消息注册表。
+var messages = &messageRegistry{entries: make(map[uint32]*messageRecord)}
+
+// messageType 返回消息的注册类型，指针类型解引用为元素类型，与 MessageID 的推导规则保持一致。
+func messageType(m any) reflect.Type {
+	typ := reflect.TypeOf(m)
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// register 登记消息 ID 与类型的映射，同一 ID 已被其他类型占用时返回错误；servers 为本次登记计入的 Server 数（0 或 1）。
+func (r *messageRegistry) register(id uint32, message any, servers int) error {
+	typ := messageType(message)
+	_, explicit := message.(IMessageID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rec, ok := r.entries[id]; ok {
+		if rec.typ != typ {
+			return fmt.Errorf("%w: message_id %d registered by %v, conflicts with %v", ErrMessageIDCollision, id, rec.typ, typ)
+		}
+		rec.servers += servers
+		return nil
+	}
+	rec := &messageRecord{
+		typ:      typ,
+		ptr:      reflect.TypeOf(message).Kind() == reflect.Pointer,
+		explicit: explicit,
+		servers:  servers,
+	}
+	r.entries[id] = rec
+	r.types.Store(id, rec)
+	return nil
+}
+
+// release 在 Server 关闭时扣减其注册消息的 Server 数；类型映射保留，以便仍可按 ID 还原消息和检测碰撞。
+func (r *messageRegistry) release(ids []uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		if rec, ok := r.entries[id]; ok && rec.servers > 0 {
+			rec.servers--
+		}
+	}
+}
+
+// RegisterType 仅将消息类型登记到全局注册表而不注册处理函数，返回其消息 ID。
+//
+// 用于跨进程传输等需要按消息 ID 还原类型的场景：响应类型（Ack）以及本进程只发送、不处理的请求类型
+// 须在解码端登记，UnmarshalMessage 才能创建对应实例。与已登记的不同类型碰撞时返回 ErrMessageIDCollision。
+func RegisterType(message any) (uint32, error) {
+	if message == nil {
+		return 0, ErrRegisterMsgNil
+	}
+	id := MessageID(message)
+	if id <= 0 {
+		return 0, fmt.Errorf("chanrpc register type: invalid message type %T", message)
+	}
+	if err := messages.register(id, message, 0); err != nil {
+		return 0, fmt.Errorf("chanrpc register type: %w", err)
+	}
+	return id, nil
+}
+
+// UnmarshalMessage 创建消息 ID 对应注册类型的新实例，交由 unmarshal 填充内容，
+// 返回与首次注册时形式一致（指针或值）的消息；消息 ID 未登记时返回 ErrUnknownMessage。
+func UnmarshalMessage(id uint32, unmarshal func(v any) error) (any, error) {
+	value, ok := messages.types.Load(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: message_id %d", ErrUnknownMessage, id)
+	}
+	rec := value.(*messageRecord)
+	v := reflect.New(rec.typ)
+	if err := unmarshal(v.Interface()); err != nil {
+		return nil, fmt.Errorf("chanrpc unmarshal message_id %d %v: %w", id, rec.typ, err)
+	}
+	if rec.ptr {
+		return v.Interface(), nil
+	}
+	return v.Elem().Interface(), nil
+}
+
+// check 校验请求类型与该 ID 已注册的类型一致，ID 尚未注册时不做判断（由 Server 报告未注册错误）。
+func (r *messageRegistry) check(id uint32, message any) error {
+	value, ok := r.types.Load(id)
+	if !ok {
+		return nil
+	}
+	if typ := messageType(message); value.(*messageRecord).typ != typ {
+		return fmt.Errorf("%w: message_id %d registered by %v, got %v", ErrMessageIDCollision, id, value.(*messageRecord).typ, typ)
+	}
+	return nil
+}
+
+// Messages 返回全局消息注册表的快照，按消息 ID 升序排列，用于排查路由问题。
+func Messages() []MessageEntry {
+	messages.mu.Lock()
+	res := make([]MessageEntry, 0, len(messages.entries))
+	for id, rec := range messages.entries {
+		res = append(res, MessageEntry{
+			ID:       id,
+			Type:     rec.typ.String(),
+			Explicit: rec.explicit,
+			Servers:  rec.servers,
+		})
+	}
+	messages.mu.Unlock()
+
+	slices.SortFunc(res, func(a, b MessageEntry) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return res
+}
+
+// DumpMessages 以"ID 类型 来源 Server 数"的文本表格形式输出全局消息注册表。
+func DumpMessages(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range Messages() {
+		source := "hash"
+		if e.Explicit {
+			source = "explicit"
+		}
+		_, _ = fmt.Fprintf(bw, "%10d\t%s\t%s\t%d\n", e.ID, e.Type, source, e.Servers)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,72 @@
+package chanrpc
+
+import (
+	"errors"
+	"testing"
+)
+
+type registryReq struct{}
+
+type registryValue struct{ N int }
+
+// registryID 以显式 ID 制造与 registryReq 的碰撞。
+type registryID struct{}
+
+func (registryID) MessageID() uint32 { return MessageID(&registryReq{}) }
+
+func messageEntry(id uint32) (MessageEntry, bool) {
+	for _, e := range Messages() {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return MessageEntry{}, false
+}
+
+func TestRegistryServersOnClose(t *testing.T) {
+	id := MessageID(&registryReq{})
+	a, b := NewServer(1), NewServer(1)
+	for _, s := range []*Server{a, b} {
+		if err := s.Register(&registryReq{}, func(*CallInfo) *RetInfo { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if e, _ := messageEntry(id); e.Servers != 2 {
+		t.Fatalf("servers = %d, want 2", e.Servers)
+	}
+
+	a.Close()
+	a.Close()
+	if e, _ := messageEntry(id); e.Servers != 1 {
+		t.Errorf("servers after close = %d, want 1", e.Servers)
+	}
+	b.Close()
+	e, ok := messageEntry(id)
+	if !ok || e.Servers != 0 {
+		t.Errorf("entry after all closed = %+v %v, want kept with 0 servers", e, ok)
+	}
+
+	if err := NewServer(1).Register(registryID{}, func(*CallInfo) *RetInfo { return nil }); !errors.Is(err, ErrMessageIDCollision) {
+		t.Errorf("register colliding type err = %v, want %v", err, ErrMessageIDCollision)
+	}
+}
+
+func TestUnmarshalMessage(t *testing.T) {
+	id, err := RegisterType(registryValue{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := UnmarshalMessage(id, func(v any) error {
+		v.(*registryValue).N = 7
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := msg.(registryValue); !ok || v.N != 7 {
+		t.Errorf("unmarshal = %#v, want registryValue{N: 7}", msg)
+	}
+	if _, err := UnmarshalMessage(id+1, func(any) error { return nil }); !errors.Is(err, ErrUnknownMessage) {
+		t.Errorf("unmarshal unknown err = %v, want %v", err, ErrUnknownMessage)
+	}
+}
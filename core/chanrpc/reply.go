@@ -0,0 +1,88 @@
+package chanrpc
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultReplyTimeout 延迟响应的默认超时时间，调用方未设置截止时间且 Defer 未指定超时时使用。
+	// 与 Call 投递超时同量级的 5 秒过短，不足以覆盖一次跨模块异步调用加数据库访问，取 30 秒。
+	defaultReplyTimeout = 30 * time.Second
+)
+
+// Reply 延迟响应句柄，由 CallInfo.Defer 创建，用于在处理函数返回之后再向调用方回包。
+//
+// 典型用法是处理函数先向其他模块发起 AsyncCall，在本模块事件循环执行的回调中完成响应：
+//
+//	func (m *Module) onQuery(ci *chanrpc.CallInfo) *chanrpc.RetInfo {
+//		reply := ci.Defer(0)
+//		_ = m.AsyncCall("db", &LoadReq{}, func(ri *chanrpc.RetInfo) {
+//			_ = reply.Done(ri.Ack, ri.Err)
+//		})
+//		return nil
+//	}
+//
+// 句柄保证只完成一次：Done、Ret、超时回包与 panic 恢复回包之间只有第一个生效，
+// 其余调用返回 ErrAlreadyReplied。
+type Reply struct {
+	ci    *CallInfo
+	done  atomic.Bool
+	timer *time.Timer
+}
+
+// Defer 将本次调用切换为延迟响应模式，处理函数的返回值将被忽略，需通过返回的句柄完成响应。
+//
+// timeout 为等待完成的最长时间，超时后自动向调用方回包 ErrReplyTimeout；
+// timeout 不大于 0 时使用调用方的截止时间（见 CallContext），调用方未设置时使用默认的 30 秒。
+// 只能在处理函数内调用，且每次调用至多调用一次；处理函数在 Defer 之后 panic 时仍会立即回包错误。
+func (ci *CallInfo) Defer(timeout time.Duration) *Reply {
+	ci.deferred = true
+	r := &Reply{ci: ci}
+	ci.reply = r
+	if ci.chanRet == nil {
+		// Cast 调用无需回包，也无需超时保护
+		return r
+	}
+
+	if timeout <= 0 {
+		timeout = defaultReplyTimeout
+		if deadline, ok := ci.Deadline(); ok {
+			timeout = max(time.Until(deadline), 0)
+		}
+	}
+	// 超时回调不访问 r.timer：AfterFunc 返回前回调就可能开始执行，此时 r.timer 尚未赋值
+	r.timer = time.AfterFunc(timeout, func() {
+		if r.done.CompareAndSwap(false, true) {
+			ci.Logger().Warnf("chanrpc message_id %d deferred reply timeout after %s", ci.MessageID(), timeout)
+			_ = ci.ret(&RetInfo{Err: ErrReplyTimeout})
+		}
+	})
+	return r
+}
+
+// Done 以响应数据和错误完成延迟响应，等价于 Ret(&RetInfo{Ack: ack, Err: err})。
+func (r *Reply) Done(ack any, err error) error {
+	return r.complete(&RetInfo{Ack: ack, Err: err})
+}
+
+// Ret 以完整的响应对象完成延迟响应，句柄已完成（含超时）时返回 ErrAlreadyReplied。
+func (r *Reply) Ret(ri *RetInfo) error {
+	return r.complete(ri)
+}
+
+// Completed 判断句柄是否已完成（含超时回包）。
+func (r *Reply) Completed() bool {
+	return r.done.Load()
+}
+
+// complete 完成响应并停止超时定时器，保证只执行一次。
+func (r *Reply) complete(ri *RetInfo) error {
+	if !r.done.CompareAndSwap(false, true) {
+		return ErrAlreadyReplied
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	return r.ci.ret(ri)
+}
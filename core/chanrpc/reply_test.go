@@ -0,0 +1,68 @@
+package chanrpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type deferReq struct {
+	Mode string
+}
+
+func TestDeferReply(t *testing.T) {
+	s := NewServer(16)
+	replies := make(chan *Reply, 1)
+	_ = s.Register(&deferReq{}, func(ci *CallInfo) *RetInfo {
+		req := ci.Request.(*deferReq)
+		switch req.Mode {
+		case "panic":
+			replies <- ci.Defer(time.Minute)
+			panic("boom")
+		case "timeout":
+			ci.Defer(20 * time.Millisecond)
+		default:
+			reply := ci.Defer(time.Minute)
+			go func() {
+				_ = reply.Done("ok", nil)
+				replies <- reply
+			}()
+		}
+		return nil
+	})
+	testServer(t, s, nil)
+	c := NewClient(16)
+
+	t.Run("done", func(t *testing.T) {
+		if ri := c.Call(s, &deferReq{}); ri.Err != nil || ri.Ack != "ok" {
+			t.Fatalf("call = %v %v, want ok", ri.Ack, ri.Err)
+		}
+		reply := <-replies
+		if err := reply.Done("again", nil); !errors.Is(err, ErrAlreadyReplied) {
+			t.Errorf("second Done err = %v, want %v", err, ErrAlreadyReplied)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		if ri := c.Call(s, &deferReq{Mode: "timeout"}); !errors.Is(ri.Err, ErrReplyTimeout) {
+			t.Fatalf("call err = %v, want %v", ri.Err, ErrReplyTimeout)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		if ri := c.Call(s, &deferReq{Mode: "panic"}); ri.Err == nil {
+			t.Fatal("call after deferred panic succeeded")
+		}
+		// panic 回包须经 Reply 完成并停止超时定时器，否则定时器到期后会重复回包
+		reply := <-replies
+		if !reply.Completed() {
+			t.Error("reply not completed after handler panic")
+		}
+		if reply.timer.Stop() {
+			t.Error("reply timer still pending after handler panic")
+		}
+		if err := reply.Done("late", nil); !errors.Is(err, ErrAlreadyReplied) {
+			t.Errorf("Done after panic err = %v, want %v", err, ErrAlreadyReplied)
+		}
+	})
+}
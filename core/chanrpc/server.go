@@ -1,36 +1,69 @@
 package chanrpc
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"reflect"
 	"runtime/debug"
+	"slices"
 	"sync/atomic"
+	"time"
 
 	"github.com/wildmap/utility/xlog"
 )
 
 // Server ChanRPC 服务端，接收并处理来自 Client 的 RPC 调用。
 //
-// 每个模块持有一个 Server 实例，所有外部 RPC 调用通过有缓冲的 ChanCall 通道排队，
-// 在模块的事件循环（Skeleton.OnStart）中串行出队处理，从而保证模块内部状态访问无并发竞争。
+// 每个模块持有一个 Server 实例，所有外部 RPC 调用按优先级进入高、普通（ChanCall）、低三条有缓冲通道排队，
+// 在模块的事件循环（Skeleton.OnStart）中通过 Poll 按优先级串行出队处理，从而保证模块内部状态访问无并发竞争。
+//
+// 自行实现事件循环的模块须改为消费全部通道：有积压时循环调用 Poll 并执行 Exec，无积压时同时 select
+// ChanCall 与 Lane(PriorityHigh)、Lane(PriorityLow)。早期版本只有 ChanCall 一条通道，仍只 select ChanCall 的事件循环
+// 不会处理通过 RegisterWithPriority、WithPriority 进入高、低优先级通道的调用，也不会处理 OverflowSpill 策略下溢出队列中的调用，
+// 这些调用将一直积压直至调用方超时。Skeleton 已按上述方式实现事件循环。
 //
 // 架构优势：消息路由通过 functions 哈希表实现 O(1) 查找，
 // 相比传统的 switch-case 分发，新增消息类型只需调用 Register 注册一次，扩展成本极低。
 type Server struct {
-	functions map[uint32]Handler // 消息 ID → 处理函数的路由表，初始化后只读，无需加锁
-	ChanCall  chan *CallInfo     // RPC 调用的缓冲通道，容量决定最大可积压的未处理调用数量
-	closed    atomic.Bool        // 关闭标志，采用原子操作保证多 goroutine 并发访问时的可见性
+	functions    map[uint32]Handler           // 消息 ID → 处理函数的路由表，初始化后只读，无需加锁
+	stats        map[uint32]*handlerStats     // 消息 ID → 执行统计，与 functions 同步注册，初始化后只读
+	priorities   map[uint32]Priority          // 消息 ID → 注册时指定的优先级，初始化后只读，未指定的消息为 PriorityNormal
+	interceptors []ServerInterceptor          // 服务端拦截器链，初始化后只读
+	fallback     Handler                      // 未注册消息的默认处理函数，初始化后只读，nil 表示返回未注册错误
+	broadcast    map[uint32]struct{}          // 通过 AcceptBroadcast 声明接收的未注册消息 ID，初始化后只读
+	broadcastAll bool                         // 是否通过 AcceptBroadcast 声明接收任意消息，初始化后只读
+	current      atomic.Pointer[SpanContext]  // 事件循环中正在处理的调用或正在执行的异步回调所处的调用，供 Client 为嵌套调用确定父调用
+	ChanCall     chan *CallInfo               // 普通优先级调用的缓冲通道，即 lanes[PriorityNormal]；不含高、低优先级通道和溢出队列中的调用，自定义事件循环应通过 Poll、Lane 消费全部调用
+	lanes        [laneCount]chan *CallInfo    // 按优先级从高到低排列的调用通道
+	skipped      [laneCount]int               // 各通道非空但被更高优先级跳过的连续次数，仅由 Poll 在事件循环中读写
+	overflow     [laneCount]overflowQueue     // 各通道的溢出队列，仅 OverflowSpill 策略使用
+	backpressure atomic.Pointer[Backpressure] // 通道已满时的处理策略，nil 表示 OverflowDefault
+	bpStats      backpressureStats            // 各背压事件的累计次数
+	closed       atomic.Bool                  // 关闭标志，采用原子操作保证多 goroutine 并发访问时的可见性
+	panics       atomic.Uint64                // Handler panic 总次数
+	abandoned    atomic.Uint64                // 出队时调用方已放弃而被丢弃的调用次数
+	coalesced    atomic.Uint64                // 合并到相同在途请求而未入队的调用次数
+	batches      atomic.Uint64                // 批量处理函数的执行次数
+	coalescing   coalescing                   // 请求合并的状态
+	batching     batching                     // 批量处理的状态，仅在事件循环中读写
+	name         string                       // 服务端名称，用于诊断信息，通常为所属模块名称
 }
 
-// NewServer 创建指定缓冲容量的 ChanRPC 服务端。
+// NewServer 创建指定缓冲容量的 ChanRPC 服务端，每条优先级通道的容量均为 callLen。
 //
-// callLen 决定消息积压的峰值上限：超出后，非阻塞模式的发送方收到 channel full 错误，
-// 阻塞模式的发送方等待超时后收到 ErrCallTimeout。
+// callLen 决定单条通道消息积压的峰值上限：超出后按 SetBackpressure 设置的策略处理，默认策略下
+// 非阻塞模式的发送方收到 ErrQueueFull，阻塞模式的发送方等待超时后收到 ErrCallTimeout。
 // 应根据模块的消息处理速率和业务峰值流量合理设置此值，过小导致背压，过大增加内存占用。
 func NewServer(callLen int) *Server {
 	s := new(Server)
 	s.functions = map[uint32]Handler{}
-	s.ChanCall = make(chan *CallInfo, callLen)
+	s.stats = map[uint32]*handlerStats{}
+	s.priorities = map[uint32]Priority{}
+	for p := range s.lanes {
+		s.lanes[p] = make(chan *CallInfo, callLen)
+	}
+	s.ChanCall = s.lanes[PriorityNormal]
 	return s
 }
 
@@ -38,7 +71,10 @@ func NewServer(callLen int) *Server {
 //
 // 每种消息类型只允许注册一个处理函数（防止意外覆盖），
 // 消息 ID 由 MessageID 函数基于类型全限定名的 BKDR 哈希自动生成，无需手动维护映射表。
-// 通常在模块的 OnInit 阶段完成注册，此后路由表只读，访问无需加锁。
+// 消息 ID 同时登记到全局注册表，与其他 Server 上已注册的不同类型碰撞时返回 ErrMessageIDCollision，
+// 此时应为其中一个类型实现 IMessageID 显式指定 ID。
+// 须在开始服务前（通常在模块的 OnInit 阶段）完成注册，此后路由表和执行统计只读，访问无需加锁，
+// Metrics 也依赖这一前提才能在其他 goroutine 中安全调用。
 func (s *Server) Register(message any, f Handler) error {
 	if message == nil {
 		return ErrRegisterMsgNil
@@ -51,14 +87,63 @@ func (s *Server) Register(message any, f Handler) error {
 		return fmt.Errorf("chanrpc register: invalid message type %v", reflect.TypeOf(message))
 	}
 
+	// 先做全局类型校验，使同一 Server 内不同类型的 ID 碰撞同样报告为 ErrMessageIDCollision
+	if err := messages.check(messageID, message); err != nil {
+		return fmt.Errorf("chanrpc register: %w", err)
+	}
 	if _, ok := s.functions[messageID]; ok {
 		return fmt.Errorf("function ID %v: already registered, type: %v", messageID, reflect.TypeOf(message))
 	}
+	if err := messages.register(messageID, message, 1); err != nil {
+		return fmt.Errorf("chanrpc register: %w", err)
+	}
 	xlog.Infof("chanrpc register: %v function ID %v", reflect.TypeOf(message), messageID)
 	s.functions[messageID] = f
+	s.stats[messageID] = newHandlerStats(reflect.TypeOf(message).String())
 	return nil
 }
 
+// SetDefaultHandler 设置未注册消息的默认处理函数，须在开始服务前调用。
+//
+// 用于代理类 Server（如跨进程传输的远程模块代理）将任意消息统一转发，而无需逐一注册消息类型；
+// 默认处理函数同样经过拦截器链，但不计入按消息类型的 Handler 统计。
+func (s *Server) SetDefaultHandler(h Handler) {
+	s.fallback = h
+}
+
+// AcceptBroadcast 声明本 Server 作为广播和多播的投递目标接收 messages 类型的消息（无需注册处理函数），
+// 不传入消息时接收任意类型，须在开始服务前调用。
+//
+// 用于设置了默认处理函数的代理类 Server：默认处理函数只决定消息如何处理，不使 Server 成为广播目标，
+// 以免代理未经声明就把所有广播转发出去。
+func (s *Server) AcceptBroadcast(messages ...any) {
+	if len(messages) == 0 {
+		s.broadcastAll = true
+		return
+	}
+	if s.broadcast == nil {
+		s.broadcast = make(map[uint32]struct{}, len(messages))
+	}
+	for _, message := range messages {
+		s.broadcast[MessageID(message)] = struct{}{}
+	}
+}
+
+// Handles 判断 request 对应的消息类型是否已在本 Server 注册处理函数或通过 AcceptBroadcast 声明接收，用于广播时筛选投递目标。
+//
+// 默认处理函数不计入，未声明接收的代理类 Server 不会成为广播目标。
+func (s *Server) Handles(request any) bool {
+	if request == nil {
+		return false
+	}
+	id := MessageID(request)
+	if _, ok := s.functions[id]; ok {
+		return true
+	}
+	_, ok := s.broadcast[id]
+	return ok || s.broadcastAll
+}
+
 // exec 执行单次 RPC 调用的核心逻辑：路由到处理函数、执行并回包。
 //
 // 防御性设计：通过 defer + recover 捕获处理函数内部抛出的 panic，
@@ -72,28 +157,72 @@ func (s *Server) exec(ci *CallInfo) (err error) {
 			} else {
 				err = fmt.Errorf("panic: %v", r)
 			}
-			xlog.Errorf("chanrpc message_id %d exec panic %v\n%s", ci.MessageID(), err, string(debug.Stack()))
+			s.panics.Add(1)
+			if hs, ok := s.stats[ci.MessageID()]; ok {
+				hs.panics.Add(1)
+			}
+			ci.Logger().Errorf("chanrpc message_id %d exec panic %v\n%s", ci.MessageID(), err, string(debug.Stack()))
 		}
-		// 确保无论正常返回还是 panic 恢复，调用方都能收到响应，避免死锁
-		if ci.hasRet.CompareAndSwap(false, true) {
+		if ci.IsCast() {
+			// Cast 不经过 ret，在此生成调用记录
+			ci.endSpan(&RetInfo{Err: err})
+		}
+		// 确保无论正常返回还是 panic 恢复，调用方都能收到响应，避免死锁；
+		// 由 ret 内部的 hasRet CAS 保证只响应一次，此处不可先行 CAS，否则 ret 会因重复响应检查而丢弃错误结果。
+		// 延迟响应模式下正常返回不回包，由 Reply 句柄完成；出错（含 panic）时仍立即回包，
+		// 且须经 Reply 句柄回包以停止其超时定时器，否则超时后会再次回包
+		if err != nil && ci.reply != nil {
+			_ = ci.reply.complete(&RetInfo{Err: err})
+		} else if (err != nil || !ci.deferred) && !ci.hasRet.Load() {
 			_ = ci.ret(&RetInfo{Err: err})
 		}
 	}()
 
+	ci.beginSpan(s.name)
+
+	// 调用方已超时或取消时直接丢弃，避免为无人等待的请求执行业务逻辑
+	// 合并请求的执行方放弃时，只要仍有其他调用方在等待，请求照常执行
+	if ci.Abandoned() && !ci.group.shared() {
+		s.abandoned.Add(1)
+		err = fmt.Errorf("chanrpc message_id %d abandoned by caller: %w", ci.MessageID(), context.Cause(ci.ctx))
+		return
+	}
+
 	// 根据消息 ID 在路由表中 O(1) 查找处理函数
 	handler, ok := s.functions[ci.MessageID()]
+	if !ok && s.fallback != nil {
+		handler, ok = s.fallback, true
+	}
 	if !ok {
 		err = fmt.Errorf("chanrpc message_id %d not registered, type: %T", ci.MessageID(), ci.Request)
 		return
 	}
 
-	ret := handler(ci)
+	// 统计 Handler 执行耗时（含 panic 路径），供 Metrics 输出耗时直方图
+	if hs, ok := s.stats[ci.MessageID()]; ok {
+		start := time.Now()
+		defer func() {
+			hs.observe(time.Since(start))
+		}()
+	}
+
+	// 处理期间发起的嵌套调用以本次调用为父调用
+	s.current.Store(&ci.span)
+	defer s.current.Store(nil)
+
+	ret := s.handle(ci, handler)
+	if ci.deferred {
+		if ret != nil {
+			ci.Logger().Warnf("chanrpc message_id %d deferred handler returned non-nil RetInfo, ignored", ci.MessageID())
+		}
+		return nil
+	}
 	return ci.ret(ret)
 }
 
 // Exec 公开的消息执行入口，在模块的 OnStart 事件循环中逐一调用。
 //
-// 执行前将 hasRet 重置为 false，允许处理函数通过 CallInfo.ret 延迟响应
+// 执行前将 hasRet 重置为 false，允许处理函数通过 CallInfo.Defer 延迟响应
 // （如异步等待数据库返回后再回包），而不强制在 handler 返回时立即响应。
 func (s *Server) Exec(ci *CallInfo) {
 	if ci == nil {
@@ -102,8 +231,9 @@ func (s *Server) Exec(ci *CallInfo) {
 	}
 	ci.hasRet.Store(false)
 	if err := s.exec(ci); err != nil {
-		xlog.Warnln(err)
+		ci.Logger().Warnln(err)
 	}
+	s.tickBatch()
 }
 
 // IsClosed 检查服务端是否已关闭。
@@ -114,8 +244,8 @@ func (s *Server) IsClosed() bool {
 // Close 关闭服务端并清空消息队列，向所有积压的调用方回包 ErrServerClosed 错误。
 //
 // 使用 CompareAndSwap 保证 Close 的幂等性（重复调用安全，不会 panic）。
-// 关闭流程：先将 closed 置为 true 阻断新调用写入 → 再 close(ChanCall) →
-// 最后排空队列中的积压消息并逐一回包，防止调用方因无响应而永久等待。
+// 关闭流程：先将 closed 置为 true 阻断新调用写入 → 从全局消息注册表扣减本 Server 的注册计数 → 再关闭全部优先级通道 →
+// 最后排空各通道中的积压消息并逐一回包，防止调用方因无响应而永久等待。
 //
 // 注意：close(ChanCall) 后立即遍历通道是安全的，
 // for range 会在通道排空后自动退出，不会阻塞。
@@ -125,13 +255,24 @@ func (s *Server) Close() {
 		xlog.Warnf("chanrpc server already closed")
 		return
 	}
+	messages.release(slices.Collect(maps.Keys(s.functions)))
 
-	close(s.ChanCall)
+	for _, lane := range s.lanes {
+		close(lane)
+	}
 
 	// 排空队列中尚未处理的调用，向每个调用方返回服务已关闭错误，避免死锁
-	for ci := range s.ChanCall {
-		_ = ci.ret(&RetInfo{
-			Err: ErrServerClosed,
-		})
+	for p, lane := range s.lanes {
+		for ci := range lane {
+			_ = ci.ret(&RetInfo{
+				Err: ErrServerClosed,
+			})
+		}
+		for _, ci := range s.overflow[p].drain() {
+			_ = ci.ret(&RetInfo{
+				Err: ErrServerClosed,
+			})
+		}
 	}
+	s.dropBatches(ErrServerClosed)
 }
@@ -0,0 +1,86 @@
+package chanrpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testServer 在独立 goroutine 中按 Skeleton 的方式运行 s 的事件循环，测试结束时停止并关闭 s。
+//
+// client 非 nil 时一并在事件循环中执行其异步回调，模拟 client 与 s 属于同一模块。
+func testServer(t *testing.T, s *Server, client *Client) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	var asyncRet chan *RetInfo
+	if client != nil {
+		asyncRet = client.ChanAsyncRet
+	}
+	go func() {
+		defer close(done)
+		high, low := s.Lane(PriorityHigh), s.Lane(PriorityLow)
+		for {
+			if ci := s.Poll(); ci != nil {
+				s.Exec(ci)
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ri := <-asyncRet:
+				client.AsyncCallback(ri)
+			case ci := <-high:
+				s.Exec(ci)
+			case ci := <-s.ChanCall:
+				s.Exec(ci)
+			case ci := <-low:
+				s.Exec(ci)
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		s.Close()
+	})
+}
+
+type echoReq struct{ N int }
+
+type panicReq struct{}
+
+func TestServerCallAndPanic(t *testing.T) {
+	s := NewServer(16)
+	_ = s.Register(&echoReq{}, func(ci *CallInfo) *RetInfo {
+		return &RetInfo{Ack: ci.Request.(*echoReq).N * 2}
+	})
+	_ = s.Register(&panicReq{}, func(ci *CallInfo) *RetInfo {
+		panic("boom")
+	})
+	testServer(t, s, nil)
+	c := NewClient(16)
+
+	if ri := c.Call(s, &echoReq{N: 21}); ri.Err != nil || ri.Ack != 42 {
+		t.Fatalf("call = %v %v, want 42", ri.Ack, ri.Err)
+	}
+	if ri := c.Call(s, &panicReq{}); ri.Err == nil || !strings.Contains(ri.Err.Error(), "boom") {
+		t.Fatalf("panic call err = %v, want panic error", ri.Err)
+	}
+	if got := s.Metrics().Panics; got != 1 {
+		t.Errorf("panics = %d, want 1", got)
+	}
+	if ri := c.Call(s, &struct{ Unknown int }{}); ri.Err == nil {
+		t.Error("call of unregistered message succeeded")
+	}
+}
+
+func TestServerClosed(t *testing.T) {
+	s := NewServer(16)
+	_ = s.Register(&echoReq{}, func(ci *CallInfo) *RetInfo { return nil })
+	s.Close()
+	if ri := NewClient(1).Call(s, &echoReq{}); !errors.Is(ri.Err, ErrServerClosed) {
+		t.Errorf("call to closed server err = %v, want %v", ri.Err, ErrServerClosed)
+	}
+}
@@ -0,0 +1,202 @@
+package chanrpc
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+// SpanContext 调用链追踪标识，TraceID 关联一次业务请求经过的全部调用，SpanID 标识其中的单次调用。
+type SpanContext struct {
+	TraceID string `json:"trace_id"`
+	SpanID  string `json:"span_id"`
+}
+
+// IsValid 判断是否携带了 TraceID。
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != ""
+}
+
+// spanCtxKey 追踪标识在 context 中的键，使用私有类型避免与其他包冲突。
+type spanCtxKey struct{}
+
+// ContextWithSpan 返回携带追踪标识的 ctx，以该 ctx 发起的 CallContext/AsyncCallContext 加入 sc 所属的调用链，并以 sc.SpanID 为父调用。
+//
+// 入口模块可用 SpanContext{TraceID: 外部请求 ID} 开启一条调用链，使日志与外部系统的请求 ID 对应。
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanCtxKey{}, sc)
+}
+
+// SpanFromContext 取出 ctx 携带的追踪标识，未携带时 ok 为 false。
+func SpanFromContext(ctx context.Context) (sc SpanContext, ok bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, ok = ctx.Value(spanCtxKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// NewTraceID 生成 128 位随机 TraceID 的十六进制表示。
+func NewTraceID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], rand.Uint64())
+	binary.BigEndian.PutUint64(b[8:], rand.Uint64())
+	return hex.EncodeToString(b[:])
+}
+
+// newSpanID 生成 64 位随机 SpanID 的十六进制表示。
+func newSpanID() string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], rand.Uint64())
+	return hex.EncodeToString(b[:])
+}
+
+// startSpan 为调用分配追踪标识，父调用按以下顺序确定：
+//  1. ctx 携带的追踪标识（ContextWithSpan 或 CallInfo.Context 返回的上下文）
+//  2. 所属模块正在处理的调用或正在执行的异步回调（需通过 SetOwner 设置所属模块）
+//  3. 均无时，设置了 SpanExporter 则开启一条新的调用链，否则不分配追踪标识
+//
+// 因此未设置导出器时，只有经 ContextWithSpan 开启的调用链会分配标识，其余调用不产生生成随机 ID 的开销。
+func (c *Client) startSpan(ci *CallInfo) {
+	parent, ok := SpanFromContext(ci.ctx)
+	if !ok && c.owner != nil {
+		if current := c.owner.current.Load(); current != nil && current.IsValid() {
+			parent, ok = *current, true
+		}
+	}
+	if !ok {
+		if spanExporter.Load() == nil {
+			return
+		}
+		parent = SpanContext{TraceID: NewTraceID()}
+	}
+	ci.span = SpanContext{TraceID: parent.TraceID, SpanID: newSpanID()}
+	ci.parentID = parent.SpanID
+}
+
+// Span 返回本次调用的追踪标识，调用未加入调用链时返回零值（IsValid 为 false）。
+func (ci *CallInfo) Span() SpanContext {
+	return ci.span
+}
+
+// ParentSpanID 返回发起本次调用时所处调用的 SpanID，调用链的第一次调用返回空字符串。
+func (ci *CallInfo) ParentSpanID() string {
+	return ci.parentID
+}
+
+// Logger 返回附加了 trace_id 和 span_id 字段的子日志器，处理函数中使用它输出的日志可按调用链关联；
+// 服务端记录的与本次调用相关的日志（panic、延迟响应超时、慢处理等）同样经由它输出。
+// 调用未加入调用链时返回不附加字段的子日志器。
+func (ci *CallInfo) Logger() xlog.ILogger {
+	if !ci.span.IsValid() {
+		return xlog.GetSubLogger()
+	}
+	return xlog.GetSubLoggerWithKeyValue(map[string]string{
+		"trace_id": ci.span.TraceID,
+		"span_id":  ci.span.SpanID,
+	})
+}
+
+// Span 追踪导出的单次调用记录，在服务端处理完成（含延迟响应完成和超时）时生成。
+type Span struct {
+	TraceID   string        `json:"trace_id"`
+	SpanID    string        `json:"span_id"`
+	ParentID  string        `json:"parent_id,omitempty"` // 父调用的 SpanID，调用链的第一次调用为空
+	Server    string        `json:"server"`              // 处理本次调用的 Server 名称
+	MessageID uint32        `json:"message_id"`
+	Message   string        `json:"message"` // 请求类型名称
+	Cast      bool          `json:"cast"`
+	Start     time.Time     `json:"start"`    // 服务端开始处理的时间
+	Duration  time.Duration `json:"duration"` // 从开始处理到回包的耗时，延迟响应计入等待时间
+	Err       string        `json:"err,omitempty"`
+}
+
+// SpanExporter 调用记录的导出器，在服务端事件循环或延迟响应完成的 goroutine 中同步调用，实现须并发安全且不应阻塞。
+type SpanExporter interface {
+	ExportSpan(span *Span)
+}
+
+// spanExporter 全局导出器，nil 表示不导出。
+var spanExporter atomic.Pointer[SpanExporter]
+
+// SetSpanExporter 设置全局调用记录导出器，对全部 Server 生效，传入 nil 关闭导出。
+//
+// 未设置导出器时服务端不记录处理时间，也不为调用方未处于调用链中的调用开启新的调用链；
+// 经 ContextWithSpan 开启的调用链仍会分配和传递追踪标识，日志关联不受影响。
+func SetSpanExporter(e SpanExporter) {
+	if e == nil {
+		spanExporter.Store(nil)
+		return
+	}
+	spanExporter.Store(&e)
+}
+
+// beginSpan 记录服务端开始处理的时间，未设置导出器时不做处理。
+func (ci *CallInfo) beginSpan(server string) {
+	if spanExporter.Load() == nil {
+		return
+	}
+	ci.started = time.Now()
+	ci.server = server
+}
+
+// endSpan 生成调用记录并交给导出器，调用未经 beginSpan 时不导出；导出器中的 panic 会被捕获并记录日志。
+func (ci *CallInfo) endSpan(ri *RetInfo) {
+	exporter := spanExporter.Load()
+	if exporter == nil || ci.started.IsZero() {
+		return
+	}
+
+	span := &Span{
+		TraceID:   ci.span.TraceID,
+		SpanID:    ci.span.SpanID,
+		ParentID:  ci.parentID,
+		Server:    ci.server,
+		MessageID: ci.messageID,
+		Message:   messageType(ci.Request).String(),
+		Cast:      ci.IsCast(),
+		Start:     ci.started,
+		Duration:  time.Since(ci.started),
+	}
+	if ri != nil && ri.Err != nil {
+		span.Err = ri.Err.Error()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			xlog.Errorf("chanrpc span exporter panic recovered, panic %v\n%s", r, string(debug.Stack()))
+		}
+	}()
+	(*exporter).ExportSpan(span)
+}
+
+// JSONLinesExporter 以 JSON Lines 格式（每行一个 Span）写出调用记录的导出器，适合写入本地文件后离线分析。
+type JSONLinesExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLinesExporter 创建写入 w 的 JSON Lines 导出器。
+//
+// 每条记录在服务端事件循环中同步写出，w 为文件时建议包装 bufio.Writer 并定期 Flush，以免磁盘 IO 拖慢事件循环。
+func NewJSONLinesExporter(w io.Writer) *JSONLinesExporter {
+	return &JSONLinesExporter{enc: json.NewEncoder(w)}
+}
+
+// ExportSpan 写出一条调用记录，实现 SpanExporter 接口，写出失败时记录警告日志。
+func (e *JSONLinesExporter) ExportSpan(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.enc.Encode(span); err != nil {
+		xlog.Warnf("chanrpc export span %s failed, err %v", span.SpanID, err)
+	}
+}
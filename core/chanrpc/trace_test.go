@@ -0,0 +1,100 @@
+package chanrpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type traceReq struct{}
+
+type traceNestedReq struct{}
+
+// spanRecorder 记录导出的调用记录。
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (r *spanRecorder) ExportSpan(span *Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func (r *spanRecorder) all() []*Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Span(nil), r.spans...)
+}
+
+// traceServers 创建 front → back 的两级调用：front 的处理函数以所属客户端同步调用 back，
+// 返回 back 观察到的追踪标识。
+func traceServers(t *testing.T) (front *Server, seen chan [2]SpanContext) {
+	seen = make(chan [2]SpanContext, 1)
+	back := NewServer(16)
+	_ = back.Register(&traceNestedReq{}, func(ci *CallInfo) *RetInfo {
+		return &RetInfo{Ack: SpanContext{TraceID: ci.Span().TraceID, SpanID: ci.ParentSpanID()}}
+	})
+	testServer(t, back, nil)
+
+	front = NewServer(16)
+	owned := NewClient(16)
+	owned.SetOwner(front)
+	_ = front.Register(&traceReq{}, func(ci *CallInfo) *RetInfo {
+		ri := owned.Call(back, &traceNestedReq{})
+		seen <- [2]SpanContext{ci.Span(), ri.Ack.(SpanContext)}
+		return nil
+	})
+	testServer(t, front, owned)
+	return front, seen
+}
+
+func TestTraceDisabledWithoutExporter(t *testing.T) {
+	front, seen := traceServers(t)
+	c := NewClient(16)
+
+	c.Call(front, &traceReq{})
+	got := <-seen
+	if got[0].IsValid() || got[1].IsValid() {
+		t.Errorf("spans = %+v, want none allocated without exporter", got)
+	}
+
+	// 显式开启的调用链仍会传递
+	root := SpanContext{TraceID: "req-1", SpanID: "root"}
+	c.CallContext(ContextWithSpan(context.Background(), root), front, &traceReq{})
+	got = <-seen
+	if got[0].TraceID != root.TraceID || got[1].TraceID != root.TraceID {
+		t.Errorf("trace ids = %q %q, want %q", got[0].TraceID, got[1].TraceID, root.TraceID)
+	}
+	if got[1].SpanID != got[0].SpanID {
+		t.Errorf("nested parent = %q, want front span %q", got[1].SpanID, got[0].SpanID)
+	}
+}
+
+func TestTraceExport(t *testing.T) {
+	rec := &spanRecorder{}
+	SetSpanExporter(rec)
+	t.Cleanup(func() { SetSpanExporter(nil) })
+
+	front, seen := traceServers(t)
+	c := NewClient(16)
+	c.Call(front, &traceReq{})
+	got := <-seen
+	if !got[0].IsValid() || got[1].TraceID != got[0].TraceID || got[1].SpanID != got[0].SpanID {
+		t.Fatalf("spans = %+v, want nested call in front's trace", got)
+	}
+
+	spans := rec.all()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	// 嵌套调用先于外层调用完成
+	nested, outer := spans[0], spans[1]
+	if outer.SpanID != got[0].SpanID || outer.ParentID != "" {
+		t.Errorf("outer span = %+v, want root span %s", outer, got[0].SpanID)
+	}
+	if nested.ParentID != outer.SpanID || nested.TraceID != outer.TraceID {
+		t.Errorf("nested span = %+v, want child of %s", nested, outer.SpanID)
+	}
+}
@@ -0,0 +1,110 @@
+package chanrpc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypeMismatchError 类型化调用中请求或响应的实际类型与声明类型不一致时返回的错误。
+//
+// 通过 errors.Is(err, ErrTypeMismatch) 判断，或 errors.As 取出具体的类型信息。
+type TypeMismatchError struct {
+	MessageID uint32 // 消息 ID，响应为 nil 或调用方无法确定时为 0
+	Kind      string // 不匹配的一方："request" 或 "response"
+	Expected  string // 声明的类型
+	Actual    string // 实际收到的类型
+}
+
+// Error 实现 error 接口。
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("chanrpc: %s type mismatch message_id %d, expected %s, got %s",
+		e.Kind, e.MessageID, e.Expected, e.Actual)
+}
+
+// Is 使 errors.Is(err, ErrTypeMismatch) 成立。
+func (e *TypeMismatchError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// RegisterTyped 以类型化处理函数注册消息，Req 的类型同时决定消息 ID，等价于以 Req 的实例调用 Register。
+//
+// 处理函数无需再对 ci.Request 做类型断言，返回值自动封装为 RetInfo{Ack: resp, Err: err}。
+// 实际请求类型与 Req 不一致时（如注册 *T 而调用方发送 T），处理函数不会被调用，
+// 调用方收到 *TypeMismatchError，而非在 Server.exec 中因断言失败而 panic。
+func RegisterTyped[Req, Resp any](s *Server, f func(req Req) (Resp, error)) error {
+	if f == nil {
+		return ErrRegisterHandlerNil
+	}
+
+	return s.Register(typedMessage[Req](), func(ci *CallInfo) *RetInfo {
+		req, ok := ci.Request.(Req)
+		if !ok {
+			return &RetInfo{Err: &TypeMismatchError{
+				MessageID: ci.MessageID(),
+				Kind:      "request",
+				Expected:  reflect.TypeFor[Req]().String(),
+				Actual:    fmt.Sprintf("%T", ci.Request),
+			}}
+		}
+		resp, err := f(req)
+		return &RetInfo{Ack: resp, Err: err}
+	})
+}
+
+// typedMessage 构造用于推导 Req 消息 ID 的非 nil 实例。
+//
+// Req 为指针类型时分配其元素类型，否则分配 *Req；MessageID 会对指针解引用一次，
+// 两种情况均映射到元素类型的 ID，且 IMessageID 的实现方不会收到 nil 接收者。
+// Req 为接口类型时返回 nil，由 Register 报告 ErrRegisterMsgNil。
+func typedMessage[Req any]() any {
+	typ := reflect.TypeFor[Req]()
+	switch typ.Kind() {
+	case reflect.Interface:
+		return nil
+	case reflect.Pointer:
+		return reflect.New(typ.Elem()).Interface()
+	default:
+		return reflect.New(typ).Interface()
+	}
+}
+
+// AckAs 将 RetInfo 中的响应数据转换为 Resp 类型。
+//
+// 调用出错时返回该错误；Ack 为 nil 时返回 Resp 的零值；类型不一致时返回 *TypeMismatchError。
+func AckAs[Resp any](ri *RetInfo) (Resp, error) {
+	var zero Resp
+	if ri == nil {
+		return zero, nil
+	}
+	if ri.Err != nil {
+		return zero, ri.Err
+	}
+	if ri.Ack == nil {
+		return zero, nil
+	}
+	resp, ok := ri.Ack.(Resp)
+	if !ok {
+		return zero, &TypeMismatchError{
+			MessageID: ri.MessageID(),
+			Kind:      "response",
+			Expected:  reflect.TypeFor[Resp]().String(),
+			Actual:    fmt.Sprintf("%T", ri.Ack),
+		}
+	}
+	return resp, nil
+}
+
+// CallTyped 发起同步调用并将响应转换为 Resp 类型，语义与 Client.Call 相同。
+func CallTyped[Resp any](c *Client, s *Server, req any) (Resp, error) {
+	return AckAs[Resp](c.Call(s, req))
+}
+
+// AsyncCallTyped 发起异步调用，回调收到已转换为 Resp 类型的响应，语义与 Client.AsyncCall 相同。
+func AsyncCallTyped[Resp any](c *Client, s *Server, req any, cb func(resp Resp, err error)) error {
+	if cb == nil {
+		return ErrCallbackNil
+	}
+	return c.AsyncCall(s, req, func(ri *RetInfo) {
+		cb(AckAs[Resp](ri))
+	})
+}
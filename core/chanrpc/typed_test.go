@@ -0,0 +1,73 @@
+package chanrpc
+
+import (
+	"errors"
+	"testing"
+)
+
+type typedReq struct{ N int }
+
+type typedAck struct{ N int }
+
+type typedWrongReq struct{}
+
+type typedNilReq struct{}
+
+func TestTypedCall(t *testing.T) {
+	s := NewServer(16)
+	if err := RegisterTyped(s, func(req *typedReq) (*typedAck, error) {
+		return &typedAck{N: req.N * 2}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Register(&typedWrongReq{}, func(*CallInfo) *RetInfo { return &RetInfo{Ack: "text"} })
+	_ = s.Register(&typedNilReq{}, func(*CallInfo) *RetInfo { return nil })
+	c := NewClient(16)
+	testServer(t, s, c)
+
+	if ack, err := CallTyped[*typedAck](c, s, &typedReq{N: 21}); err != nil || ack.N != 42 {
+		t.Fatalf("call = %+v %v, want 42", ack, err)
+	}
+
+	// 注册 *T 而发送 T：处理函数不执行，返回请求类型不匹配
+	_, err := CallTyped[*typedAck](c, s, typedReq{N: 1})
+	var mismatch *TypeMismatchError
+	if !errors.Is(err, ErrTypeMismatch) || !errors.As(err, &mismatch) || mismatch.Kind != "request" {
+		t.Errorf("value request err = %v, want request type mismatch", err)
+	}
+
+	_, err = CallTyped[*typedAck](c, s, &typedWrongReq{})
+	if !errors.As(err, &mismatch) || mismatch.Kind != "response" || mismatch.Actual != "string" {
+		t.Errorf("wrong ack err = %v, want response type mismatch", err)
+	}
+
+	if ack, err := CallTyped[*typedAck](c, s, &typedNilReq{}); err != nil || ack != nil {
+		t.Errorf("nil ack = %+v %v, want zero value", ack, err)
+	}
+
+	done := make(chan *typedAck, 1)
+	if err := AsyncCallTyped(c, s, &typedReq{N: 2}, func(ack *typedAck, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		done <- ack
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if ack := <-done; ack.N != 4 {
+		t.Errorf("async ack = %+v, want 4", ack)
+	}
+}
+
+func TestTypedRegisterErrors(t *testing.T) {
+	s := NewServer(1)
+	if err := RegisterTyped(s, func(req error) (any, error) { return nil, nil }); !errors.Is(err, ErrRegisterMsgNil) {
+		t.Errorf("interface request err = %v, want %v", err, ErrRegisterMsgNil)
+	}
+	if err := RegisterTyped[*typedReq, any](s, nil); !errors.Is(err, ErrRegisterHandlerNil) {
+		t.Errorf("nil handler err = %v, want %v", err, ErrRegisterHandlerNil)
+	}
+	if err := AsyncCallTyped[*typedAck](NewClient(1), s, &typedReq{}, nil); !errors.Is(err, ErrCallbackNil) {
+		t.Errorf("nil callback err = %v, want %v", err, ErrCallbackNil)
+	}
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IDependent 模块依赖声明接口，模块可选实现，用于声明其依赖的其他模块名称。
+//
+// 实现此接口的模块会在其所有依赖模块之后初始化、启动，并在依赖模块之前关闭，
+// 调用方无需再手动调整 Run/Register 的参数顺序。未实现此接口的模块视为无依赖。
+type IDependent interface {
+	Dependencies() []string // 依赖的模块名称列表，名称需与目标模块的 Name() 一致
+}
+
+// moduleDependencies 获取模块声明的依赖列表，未实现 IDependent 的模块返回 nil。
+//
+// moduleWrapper 只提升 IModule 的方法，须取出被包装的模块再判断是否实现 IDependent。
+func moduleDependencies(mod IModule) []string {
+	if wrapper, ok := mod.(*moduleWrapper); ok {
+		mod = wrapper.IModule
+	}
+	if dep, ok := mod.(IDependent); ok {
+		return dep.Dependencies()
+	}
+	return nil
+}
+
+// sortModules 按依赖关系对模块做拓扑排序，返回满足"被依赖模块在前"的启动顺序。
+//
+// 排序采用 Kahn 算法，每轮在入度为 0 的模块中按原始注册顺序选取，
+// 保证无依赖约束的模块之间维持注册顺序，排序结果稳定、可预期。
+//
+// external 用于判断批次外的模块是否已存在（如动态模块依赖已运行的静态模块），
+// 依赖命中 external 时视为已满足，不参与排序；为 nil 表示所有依赖必须在批次内。
+//
+// 以下情况返回错误：模块名称重复、依赖的模块不存在、依赖关系存在环。
+func sortModules(wrappers []*moduleWrapper, external func(name string) bool) ([]*moduleWrapper, error) {
+	index := make(map[string]int, len(wrappers))
+	for i, wrapper := range wrappers {
+		name := wrapper.Name()
+		if _, ok := index[name]; ok {
+			return nil, fmt.Errorf("module %s registered twice", name)
+		}
+		index[name] = i
+	}
+
+	// edges[i] 记录依赖模块 i 的模块下标集合，inDegree[i] 为模块 i 尚未满足的批次内依赖数量
+	edges := make([][]int, len(wrappers))
+	inDegree := make([]int, len(wrappers))
+	for i, wrapper := range wrappers {
+		for _, dep := range moduleDependencies(wrapper) {
+			if dep == wrapper.Name() {
+				return nil, fmt.Errorf("module %s cannot depend on itself", dep)
+			}
+			j, ok := index[dep]
+			if !ok {
+				if external != nil && external(dep) {
+					continue
+				}
+				return nil, fmt.Errorf("module %s depends on unknown module %s", wrapper.Name(), dep)
+			}
+			edges[j] = append(edges[j], i)
+			inDegree[i]++
+		}
+	}
+
+	sorted := make([]*moduleWrapper, 0, len(wrappers))
+	done := make([]bool, len(wrappers))
+	for len(sorted) < len(wrappers) {
+		next := -1
+		for i := range wrappers {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("module dependency cycle detected: %s", findCycle(wrappers, index, done))
+		}
+		done[next] = true
+		sorted = append(sorted, wrappers[next])
+		for _, i := range edges[next] {
+			inDegree[i]--
+		}
+	}
+	return sorted, nil
+}
+
+// findCycle 在尚未排序的模块中查找一条依赖环，返回形如 "a -> b -> a" 的路径描述，用于错误提示。
+//
+// 仅在 Kahn 算法无法继续推进时调用，此时剩余模块中必然存在环，
+// 从任一剩余模块出发沿依赖边前进，首次回到已访问节点时即构成一个环。
+func findCycle(wrappers []*moduleWrapper, index map[string]int, done []bool) string {
+	start := -1
+	for i := range wrappers {
+		if !done[i] {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+
+	visited := make(map[int]int) // 模块下标 → 在 path 中的位置
+	var path []string
+	cur := start
+	for {
+		if pos, ok := visited[cur]; ok {
+			path = append(path[pos:], wrappers[cur].Name())
+			return strings.Join(path, " -> ")
+		}
+		visited[cur] = len(path)
+		path = append(path, wrappers[cur].Name())
+
+		next := -1
+		for _, dep := range moduleDependencies(wrappers[cur]) {
+			if j, ok := index[dep]; ok && !done[j] {
+				next = j
+				break
+			}
+		}
+		if next < 0 {
+			return strings.Join(path, " -> ")
+		}
+		cur = next
+	}
+}
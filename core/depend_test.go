@@ -0,0 +1,128 @@
+package core
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSortModules(t *testing.T) {
+	tests := []struct {
+		name     string
+		mods     []*testModule
+		external []string
+		want     []string
+		err      string
+	}{
+		{
+			name: "keeps registration order without dependencies",
+			mods: []*testModule{{name: "a"}, {name: "b"}, {name: "c"}},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "dependencies first",
+			mods: []*testModule{
+				{name: "gate", deps: []string{"world", "db"}},
+				{name: "world", deps: []string{"db"}},
+				{name: "log"},
+				{name: "db"},
+			},
+			want: []string{"log", "db", "world", "gate"},
+		},
+		{
+			name:     "external dependency satisfied",
+			mods:     []*testModule{{name: "battle", deps: []string{"world"}}},
+			external: []string{"world"},
+			want:     []string{"battle"},
+		},
+		{
+			name: "cycle",
+			mods: []*testModule{
+				{name: "log"},
+				{name: "a", deps: []string{"b"}},
+				{name: "b", deps: []string{"c"}},
+				{name: "c", deps: []string{"a"}},
+			},
+			err: "module dependency cycle detected: a -> b -> c -> a",
+		},
+		{
+			name: "self dependency",
+			mods: []*testModule{{name: "a", deps: []string{"a"}}},
+			err:  "module a cannot depend on itself",
+		},
+		{
+			name: "unknown dependency",
+			mods: []*testModule{{name: "a", deps: []string{"missing"}}},
+			err:  "module a depends on unknown module missing",
+		},
+		{
+			name: "duplicate name",
+			mods: []*testModule{{name: "a"}, {name: "b"}, {name: "a"}},
+			err:  "module a registered twice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApp()
+			var wrappers []*moduleWrapper
+			for _, m := range tt.mods {
+				wrappers = append(wrappers, app.newModuleWrapper("static", m))
+			}
+			var external func(string) bool
+			if tt.external != nil {
+				external = func(name string) bool { return slices.Contains(tt.external, name) }
+			}
+
+			sorted, err := sortModules(wrappers, external)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, w := range sorted {
+				names = append(names, w.Name())
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("order = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppLifecycleFollowsDependencies(t *testing.T) {
+	var log []string
+	app := NewApp()
+	app.SetInitWorkers(1)
+	ok := app.start(
+		&testModule{name: "gate", deps: []string{"world"}, log: &log},
+		&testModule{name: "world", deps: []string{"db"}, log: &log},
+		&testModule{name: "db", log: &log},
+	)
+	if !ok {
+		t.Fatal("start failed")
+	}
+	app.stop()
+
+	want := []string{"init db", "init world", "init gate", "destroy gate", "destroy world", "destroy db"}
+	if !slices.Equal(log, want) {
+		t.Errorf("lifecycle order = %v, want %v", log, want)
+	}
+}
+
+func TestAppStartRejectsCycle(t *testing.T) {
+	a := &testModule{name: "a", deps: []string{"b"}}
+	b := &testModule{name: "b", deps: []string{"a"}}
+	app := NewApp()
+	if app.start(a, b) {
+		t.Fatal("start with dependency cycle succeeded")
+	}
+	if inited, _ := a.state(); inited {
+		t.Error("module initialized despite dependency cycle")
+	}
+}
@@ -0,0 +1,18 @@
+// Package core 提供基于 Actor 模型的模块化应用框架。
+//
+// 应用由若干实现 IModule 的模块组成，每个模块在独立的 goroutine 中运行，模块之间通过 chanrpc 通信：
+//
+//	core.Run(gate, world, db)
+//
+// Run 按 IDependent 声明的依赖关系依次初始化并启动模块，收到 SIGINT/SIGTERM 后按相反顺序关闭，
+// 收到 SIGHUP 时重新加载实现了 IReloadable 的模块。模块通常内嵌 Skeleton，
+// 由其提供事件循环、RPC 收发和定时器。
+//
+// # 关闭流程
+//
+// Run 在关闭完成后返回每个模块的 ShutdownResult，早期版本的 Run 没有返回值。
+// 模块关闭超时后的默认处理也已改变：早期版本在超时后不再等待，直接调用 OnDestroy，
+// 现在默认策略 ShutdownSkipDestroy 会跳过仍在运行的模块的 OnDestroy，避免与模块 goroutine 并发释放资源；
+// 必须执行 OnDestroy 的应用可改用 ShutdownWait，在超时后继续等待模块退出再销毁。
+// 单个模块的超时时间可通过 IShutdownTimeout 声明，整个关闭流程的总预算通过 SetShutdownBudget 设置。
+package core
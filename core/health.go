@@ -0,0 +1,221 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+const (
+	// defaultHealthCheckInterval 模块健康检查的默认轮询周期。
+	// 5 秒兼顾编排系统探针的响应速度与检查逻辑本身的开销。
+	defaultHealthCheckInterval = 5 * time.Second
+)
+
+// IHealthChecker 模块健康检查接口，模块可选实现，用于向框架上报存活性和就绪性。
+//
+// 两个方法均由框架的健康检查 goroutine 周期性调用（新加入的模块在首次查询时调用），而非在模块自身的事件循环中执行，
+// 实现方需保证其并发安全（如读取 atomic 变量），且不应执行阻塞操作。
+// 返回 nil 表示检查通过，返回的 error 文本作为不健康原因对外暴露。
+type IHealthChecker interface {
+	Liveness() error  // 存活性检查，失败表示模块已不可恢复，通常需要重启进程
+	Readiness() error // 就绪性检查，失败表示模块暂时无法对外提供服务（如依赖连接尚未建立）
+}
+
+// IServing 模块服务状态接口，模块可选实现，用于告知框架其 OnRun 主循环是否已真正开始处理消息。
+//
+// Skeleton 已实现此接口，内嵌 Skeleton 的模块无需额外处理。
+// 未实现此接口的模块在其 OnRun goroutine 启动后即视为处于服务状态。
+type IServing interface {
+	Serving() bool
+}
+
+// ModuleHealth 单个模块的健康检查结果。
+type ModuleHealth struct {
+	Name      string    `json:"name"`       // 模块名称
+	Kind      string    `json:"kind"`       // 模块类型：static 或 dynamic
+	Live      bool      `json:"live"`       // 存活性：OnRun goroutine 仍在运行且 Liveness 检查通过
+	Ready     bool      `json:"ready"`      // 就绪性：模块存活、主循环已在服务且 Readiness 检查通过
+	Reason    string    `json:"reason"`     // 不健康原因，健康时为空
+	CheckedAt time.Time `json:"checked_at"` // IHealthChecker 的检查时间，未实现该接口的模块为查询时间
+}
+
+// HealthReport 应用整体健康状态，由各模块的检查结果聚合而成。
+//
+// Live 要求所有模块存活；Ready 额外要求应用处于 AppStateRun 状态且所有模块就绪，
+// 因此在初始化阶段和关闭阶段 Ready 恒为 false，编排系统可据此摘除流量。
+type HealthReport struct {
+	State   int32          `json:"state"`   // 应用当前状态
+	Live    bool           `json:"live"`    // 应用整体存活性
+	Ready   bool           `json:"ready"`   // 应用整体就绪性
+	Modules []ModuleHealth `json:"modules"` // 各模块检查结果，静态模块在前，动态模块在后
+}
+
+// checkerResult 模块 IHealthChecker 最近一次轮询的结果。
+type checkerResult struct {
+	liveness  error // Liveness 的检查结果
+	readiness error // Readiness 的检查结果，Liveness 失败时不检查
+	checkedAt time.Time
+}
+
+// SetHealthCheckInterval 设置模块健康检查的轮询周期，须在 Run 之前调用，d 不大于 0 时使用默认值。
+func (a *App) SetHealthCheckInterval(d time.Duration) {
+	if d <= 0 {
+		d = defaultHealthCheckInterval
+	}
+	a.healthInterval.Store(int64(d))
+}
+
+// Health 返回应用整体健康状态。
+//
+// 应用状态、模块集合、OnRun goroutine 是否运行和主循环是否已在服务（IServing）均在调用时实时读取，
+// 模块启动或关闭后立即反映到结果中，无需等待下一次轮询；
+// 仅 IHealthChecker 的检查结果取自最近一次轮询的缓存，尚未轮询到的模块在本次调用中直接检查。
+func (a *App) Health() HealthReport {
+	report := HealthReport{
+		State: a.GetState(),
+		Live:  true,
+		Ready: true,
+	}
+	var cached map[*moduleWrapper]checkerResult
+	if p := a.health.Load(); p != nil {
+		cached = *p
+	}
+	a.rangeModules(func(kind string, wrapper *moduleWrapper) {
+		report.Modules = append(report.Modules, a.checkModuleHealth(kind, wrapper, cached))
+	})
+
+	if len(report.Modules) == 0 {
+		report.Ready = false
+	}
+	for _, mh := range report.Modules {
+		report.Live = report.Live && mh.Live
+		report.Ready = report.Ready && mh.Ready
+	}
+	if report.State != AppStateRun {
+		report.Ready = false
+	}
+	return report
+}
+
+// rangeModules 依次访问全部静态模块和动态模块。
+func (a *App) rangeModules(f func(kind string, wrapper *moduleWrapper)) {
+	a.RLock()
+	for _, wrapper := range a.modules {
+		f("static", wrapper)
+	}
+	a.RUnlock()
+
+	a.dynamicModules.Range(func(key, value any) bool {
+		if wrapper, ok := value.(*moduleWrapper); ok {
+			f("dynamic", wrapper)
+		}
+		return true
+	})
+}
+
+// runHealthCheck 在独立 goroutine 中周期性执行 IHealthChecker 检查，直到 ctx 被取消。
+//
+// 启动时立即执行一次，使 Health 在应用进入运行状态后尽快反映真实结果。
+func (a *App) runHealthCheck(ctx context.Context) {
+	interval := time.Duration(a.healthInterval.Load())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	a.checkHealth()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkHealth()
+		}
+	}
+}
+
+// checkHealth 对所有实现了 IHealthChecker 的模块（静态 + 动态）执行一轮检查，并原子替换缓存结果。
+//
+// 缓存以 moduleWrapper 为键，同名动态模块被移除后重新加载时不会沿用旧实例的结果。
+func (a *App) checkHealth() {
+	results := make(map[*moduleWrapper]checkerResult)
+	a.rangeModules(func(_ string, wrapper *moduleWrapper) {
+		if checker, ok := wrapper.IModule.(IHealthChecker); ok {
+			results[wrapper] = runChecker(wrapper.Name(), checker)
+		}
+	})
+	a.health.Store(&results)
+}
+
+// runChecker 执行模块的 Liveness 和 Readiness 检查，其中的 panic 会被捕获并视为存活性检查失败，
+// 避免影响健康检查 goroutine 或 Health 的调用方。
+func runChecker(name string, checker IHealthChecker) (res checkerResult) {
+	res.checkedAt = time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			xlog.Errorf("module %s health check panic recovered, panic %v\n%s", name, r, string(debug.Stack()))
+			res.liveness = fmt.Errorf("health check panic: %v", r)
+		}
+	}()
+
+	if res.liveness = checker.Liveness(); res.liveness == nil {
+		res.readiness = checker.Readiness()
+	}
+	return
+}
+
+// checkModuleHealth 检查单个模块的健康状态，cached 为最近一次轮询的 IHealthChecker 结果。
+//
+// 判定顺序：OnRun goroutine 是否仍在运行 → Liveness → 主循环是否已在服务 → Readiness，
+// 前一项失败时后续项不再检查，Reason 记录首个失败原因。
+func (a *App) checkModuleHealth(kind string, wrapper *moduleWrapper, cached map[*moduleWrapper]checkerResult) (mh ModuleHealth) {
+	mh = ModuleHealth{
+		Name:      wrapper.Name(),
+		Kind:      kind,
+		CheckedAt: time.Now(),
+	}
+
+	if !wrapper.running.Load() {
+		mh.Reason = fmt.Sprintf("module is not running, status %s", moduleStatus(wrapper.status.Load()))
+		return
+	}
+
+	var res checkerResult
+	checker, hasChecker := wrapper.IModule.(IHealthChecker)
+	if hasChecker {
+		var ok bool
+		if res, ok = cached[wrapper]; !ok {
+			res = runChecker(wrapper.Name(), checker)
+		}
+		mh.CheckedAt = res.checkedAt
+		if res.liveness != nil {
+			mh.Reason = res.liveness.Error()
+			return
+		}
+	}
+	mh.Live = true
+
+	if serving, ok := wrapper.IModule.(IServing); ok && !isServing(serving) {
+		mh.Reason = "module is not serving yet"
+		return
+	}
+	if res.readiness != nil {
+		mh.Reason = res.readiness.Error()
+		return
+	}
+	mh.Ready = true
+	return
+}
+
+// isServing 调用 IServing.Serving，其中的 panic 会被捕获并视为未在服务。
+func isServing(serving IServing) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			xlog.Errorf("module serving check panic recovered, panic %v\n%s", r, string(debug.Stack()))
+			ok = false
+		}
+	}()
+	return serving.Serving()
+}
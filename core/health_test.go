@@ -0,0 +1,92 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// servingModule 可控制服务状态和就绪检查结果的测试模块。
+type servingModule struct {
+	testModule
+	serving   atomic.Bool
+	readiness atomic.Pointer[error]
+}
+
+func (m *servingModule) Serving() bool { return m.serving.Load() }
+
+func (m *servingModule) Liveness() error { return nil }
+
+func (m *servingModule) Readiness() error {
+	if err := m.readiness.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func TestHealthReadyFollowsServing(t *testing.T) {
+	app := NewApp()
+	mod := &servingModule{testModule: testModule{name: "m"}}
+	wrapper := app.newModuleWrapper("static", mod)
+	wrapper.running.Store(true)
+	app.modules = append(app.modules, wrapper)
+	app.setState(AppStateRun)
+	app.checkHealth()
+
+	if report := app.Health(); report.Ready || !report.Live {
+		t.Fatalf("before serving live %v ready %v, want live and not ready", report.Live, report.Ready)
+	}
+
+	// 模块开始服务后无需等待下一次轮询即可就绪
+	mod.serving.Store(true)
+	if report := app.Health(); !report.Ready {
+		t.Fatalf("after serving ready = false, reason %q", report.Modules[0].Reason)
+	}
+
+	errNotReady := errors.New("connection not ready")
+	mod.readiness.Store(&errNotReady)
+	if report := app.Health(); !report.Ready {
+		t.Fatal("readiness result should be cached until next poll")
+	}
+	app.checkHealth()
+	if report := app.Health(); report.Ready || report.Modules[0].Reason != errNotReady.Error() {
+		t.Fatalf("after poll ready %v reason %q, want not ready", report.Ready, report.Modules[0].Reason)
+	}
+
+	wrapper.running.Store(false)
+	mod.readiness.Store(nil)
+	app.checkHealth()
+	if report := app.Health(); report.Live {
+		t.Fatal("stopped module reported live")
+	}
+}
+
+func TestHealthChecksNewDynamicModule(t *testing.T) {
+	app := NewApp()
+	app.setState(AppStateRun)
+	app.checkHealth()
+
+	mod := &servingModule{testModule: testModule{name: "d"}}
+	mod.serving.Store(true)
+	if err := app.AddDynamicModules(mod); err != nil {
+		t.Fatal(err)
+	}
+	defer app.RemoveDynamicModule("d")
+
+	// OnRun goroutine 异步启动，未轮询过的模块应在查询时直接检查
+	deadline := time.Now().Add(time.Second)
+	for {
+		report := app.Health()
+		if len(report.Modules) != 1 || report.Modules[0].Name != "d" {
+			t.Fatalf("modules = %+v, want dynamic module d", report.Modules)
+		}
+		if report.Ready {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("dynamic module not ready, reason %q", report.Modules[0].Reason)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+const (
+	// defaultInitWorkers 模块并发初始化的默认并发数。
+	// 默认值为 1 即串行初始化，与未声明依赖的既有模块依赖注册顺序的行为保持兼容，
+	// 需要并发初始化的应用通过 SetInitWorkers 显式开启。
+	defaultInitWorkers = 1
+)
+
+// initResult 单个模块 OnInit 的执行结果，由初始化 goroutine 回传给调度循环。
+type initResult struct {
+	index int   // 模块在待初始化列表中的下标
+	err   error // OnInit 返回的错误或 panic 转换后的错误
+}
+
+// initModules 按依赖关系并发初始化一批模块，最多同时执行 initWorkers 个 OnInit。
+//
+// 调度策略：wrappers 须为 sortModules 的输出，模块在其批次内的全部依赖初始化成功后才进入就绪状态，
+// 就绪模块按排序顺序依次派发，workers 为 1 时退化为与原有实现一致的串行初始化。
+//
+// 失败处理：任一模块初始化失败后停止派发新模块，等待执行中的 OnInit 全部返回，
+// 再按初始化完成顺序的逆序对已成功初始化的模块调用 OnDestroy，避免资源泄漏。
+// 返回所有失败模块的错误（errors.Join 合并）。
+func (a *App) initModules(wrappers []*moduleWrapper) error {
+	workers := int(a.initWorkers.Load())
+	if workers < 1 {
+		workers = 1
+	}
+
+	index := make(map[string]int, len(wrappers))
+	for i, wrapper := range wrappers {
+		index[wrapper.Name()] = i
+	}
+
+	// 仅统计批次内依赖，批次外依赖（已运行的模块）视为已满足
+	dependents := make([][]int, len(wrappers))
+	waiting := make([]int, len(wrappers))
+	for i, wrapper := range wrappers {
+		for _, dep := range moduleDependencies(wrapper) {
+			if j, ok := index[dep]; ok {
+				dependents[j] = append(dependents[j], i)
+				waiting[i]++
+			}
+		}
+	}
+
+	var ready []int
+	for i := range wrappers {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	results := make(chan initResult, len(wrappers))
+	var (
+		inited  []*moduleWrapper // 已成功初始化的模块，按完成顺序排列，用于失败时逆序回滚
+		errs    []error
+		running int
+	)
+	// 出现失败后不再派发，仅等待执行中的 OnInit 返回，就绪队列中剩余的模块不再初始化
+	for running > 0 || (len(errs) == 0 && len(ready) > 0) {
+		// 未出现失败时，在并发数上限内派发就绪模块
+		for len(errs) == 0 && len(ready) > 0 && running < workers {
+			i := ready[0]
+			ready = ready[1:]
+			running++
+			go func(i int) {
+				results <- initResult{index: i, err: a.initModule(wrappers[i])}
+			}(i)
+		}
+
+		res := <-results
+		running--
+		wrapper := wrappers[res.index]
+		if res.err != nil {
+			xlog.Errorf("module %s initialization failed, err %v", wrapper.Name(), res.err)
+			errs = append(errs, fmt.Errorf("module %s init failed: %w", wrapper.Name(), res.err))
+			continue
+		}
+
+		inited = append(inited, wrapper)
+		for _, i := range dependents[res.index] {
+			waiting[i]--
+			if waiting[i] == 0 {
+				// 按下标有序插入，使就绪模块始终按拓扑排序结果的顺序派发
+				pos, _ := slices.BinarySearch(ready, i)
+				ready = slices.Insert(ready, pos, i)
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	for i := len(inited) - 1; i >= 0; i-- {
+		xlog.Infof("rollback initialized module %s", inited[i].Name())
+		a.destroyModule(inited[i])
+	}
+	return errors.Join(errs...)
+}
+
+// initModule 执行单个模块的 OnInit，并将其中的 panic 转换为错误，前后分别触发 EventBeforeInit 和 EventAfterInit。
+//
+// OnInit 运行在独立 goroutine 中，未捕获的 panic 会直接导致进程崩溃且跳过回滚，
+// 转换为错误后可与普通初始化失败走同一回滚流程。
+func (a *App) initModule(wrapper *moduleWrapper) (err error) {
+	a.emit(EventBeforeInit, wrapper, 0, nil)
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			xlog.Errorf("module %s init panic recovered, panic %v\n%s", wrapper.Name(), r, string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+		a.emit(EventAfterInit, wrapper, time.Since(start), err)
+	}()
+
+	return wrapper.OnInit()
+}
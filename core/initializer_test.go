@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+// testModule 测试用模块，记录生命周期回调的执行情况。
+type testModule struct {
+	name    string
+	deps    []string
+	initErr error
+	run     func(ctx context.Context)
+
+	mu        sync.Mutex
+	inited    bool
+	destroyed bool
+	log       *[]string // 多个模块共享的回调顺序记录，仅用于串行的生命周期流程
+}
+
+func (m *testModule) Name() string { return m.name }
+
+func (m *testModule) Dependencies() []string { return m.deps }
+
+func (m *testModule) OnInit() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inited = true
+	m.record("init " + m.name)
+	return m.initErr
+}
+
+func (m *testModule) OnRun(ctx context.Context) {
+	if m.run != nil {
+		m.run(ctx)
+		return
+	}
+	<-ctx.Done()
+}
+
+func (m *testModule) OnDestroy() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.destroyed = true
+	m.record("destroy " + m.name)
+}
+
+func (m *testModule) ChanRPC() *chanrpc.Server { return nil }
+
+func (m *testModule) record(s string) {
+	if m.log != nil {
+		*m.log = append(*m.log, s)
+	}
+}
+
+func (m *testModule) state() (inited, destroyed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inited, m.destroyed
+}
+
+func TestInitModulesFailureWithIndependentReady(t *testing.T) {
+	errInit := errors.New("init failed")
+	c := &testModule{name: "c"}
+	a := &testModule{name: "a", initErr: errInit}
+	b := &testModule{name: "b"}
+
+	app := NewApp()
+	app.SetInitWorkers(1)
+	wrappers := []*moduleWrapper{
+		app.newModuleWrapper("static", c),
+		app.newModuleWrapper("static", a),
+		app.newModuleWrapper("static", b),
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- app.initModules(wrappers)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("initModules hangs after init failure")
+	}
+	if !errors.Is(err, errInit) {
+		t.Errorf("initModules err = %v, want %v", err, errInit)
+	}
+	if inited, destroyed := c.state(); !inited || !destroyed {
+		t.Errorf("module c inited %v destroyed %v, want rolled back", inited, destroyed)
+	}
+	if inited, _ := b.state(); inited {
+		t.Error("module b initialized after failure")
+	}
+	if _, destroyed := a.state(); destroyed {
+		t.Error("failed module a destroyed")
+	}
+}
+
+func TestInitModulesRollbackOrder(t *testing.T) {
+	var log []string
+	errInit := errors.New("init failed")
+	base := &testModule{name: "base", log: &log}
+	mid := &testModule{name: "mid", deps: []string{"base"}, log: &log}
+	top := &testModule{name: "top", deps: []string{"mid"}, initErr: errInit, log: &log}
+
+	app := NewApp()
+	wrappers, err := sortModules([]*moduleWrapper{
+		app.newModuleWrapper("static", top),
+		app.newModuleWrapper("static", mid),
+		app.newModuleWrapper("static", base),
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.initModules(wrappers); !errors.Is(err, errInit) {
+		t.Fatalf("initModules err = %v, want %v", err, errInit)
+	}
+
+	want := []string{"init base", "init mid", "init top", "destroy mid", "destroy base"}
+	if !slices.Equal(log, want) {
+		t.Errorf("lifecycle order = %v, want %v", log, want)
+	}
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+// LifecycleEvent 模块生命周期事件类型。
+type LifecycleEvent int32
+
+const (
+	EventBeforeInit     LifecycleEvent = iota // 即将调用 OnInit
+	EventAfterInit                            // OnInit 返回，Err 为初始化错误，Duration 为 OnInit 耗时
+	EventStarted                              // OnRun 开始运行，监督策略重启时会再次触发
+	EventPanicked                             // OnRun 发生 panic，Err 为 panic 信息，Duration 为本轮 OnRun 运行时长
+	EventStopping                             // 框架已发出停止信号，Err 为停止原因（ErrAppShutdown 等）
+	EventDestroyed                            // OnDestroy 返回，Err 为销毁 panic 信息，Duration 为 OnDestroy 耗时
+	EventDynamicAdded                         // 动态模块已加入动态模块集合并启动，Duration 为从 OnInit 开始到启动的耗时
+	EventDynamicRemoved                       // 动态模块已从动态模块集合移除，Duration 为整个停止流程的耗时
+)
+
+// String 返回生命周期事件的可读名称，用于日志输出。
+func (e LifecycleEvent) String() string {
+	switch e {
+	case EventBeforeInit:
+		return "before_init"
+	case EventAfterInit:
+		return "after_init"
+	case EventStarted:
+		return "started"
+	case EventPanicked:
+		return "panicked"
+	case EventStopping:
+		return "stopping"
+	case EventDestroyed:
+		return "destroyed"
+	case EventDynamicAdded:
+		return "dynamic_added"
+	case EventDynamicRemoved:
+		return "dynamic_removed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(e))
+	}
+}
+
+// LifecycleInfo 生命周期事件的详细信息。
+type LifecycleInfo struct {
+	Event    LifecycleEvent `json:"event"`    // 事件类型
+	Name     string         `json:"name"`     // 模块名称
+	Kind     string         `json:"kind"`     // 模块类型：static 或 dynamic
+	Duration time.Duration  `json:"duration"` // 事件相关阶段的耗时，无意义时为 0
+	Err      error          `json:"-"`        // 事件相关的错误，无错误时为 nil；JSON 中以 error 字段输出错误文本
+	At       time.Time      `json:"at"`       // 事件发生时间
+}
+
+// MarshalJSON 实现 json.Marshaler 接口，Err 以错误文本输出到 error 字段，无错误时省略。
+func (i LifecycleInfo) MarshalJSON() ([]byte, error) {
+	type plain LifecycleInfo
+	return json.Marshal(struct {
+		plain
+		Error string `json:"error,omitempty"`
+	}{plain(i), errorText(i.Err)})
+}
+
+// LifecycleHook 生命周期事件回调，用于审计日志、服务发现注册等外部观察场景。
+//
+// 回调在触发事件的 goroutine 中同步执行（可能是模块 goroutine、初始化 goroutine 或关闭流程），
+// 多个事件可能并发回调，实现方需保证并发安全且不应阻塞；回调中的 panic 会被捕获并记录日志。
+type LifecycleHook func(info LifecycleInfo)
+
+// lifecycleHooks 已注册的生命周期回调，写时复制：注册时替换为新切片，触发时仅在锁内取切片引用，回调在锁外执行。
+type lifecycleHooks struct {
+	mu    sync.Mutex
+	hooks []LifecycleHook
+}
+
+// AddLifecycleHook 注册生命周期事件回调，对静态模块和动态模块均生效，可在 Run 前后任意时刻调用。
+//
+// 回调按注册顺序依次执行，注册之前已发生的事件不会补发。
+func (a *App) AddLifecycleHook(hook LifecycleHook) {
+	if hook == nil {
+		return
+	}
+	a.lifecycle.mu.Lock()
+	defer a.lifecycle.mu.Unlock()
+
+	hooks := make([]LifecycleHook, 0, len(a.lifecycle.hooks)+1)
+	hooks = append(hooks, a.lifecycle.hooks...)
+	a.lifecycle.hooks = append(hooks, hook)
+}
+
+// emit 向所有已注册的回调发送生命周期事件。
+func (a *App) emit(event LifecycleEvent, wrapper *moduleWrapper, d time.Duration, err error) {
+	a.lifecycle.mu.Lock()
+	hooks := a.lifecycle.hooks
+	a.lifecycle.mu.Unlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	info := LifecycleInfo{
+		Event:    event,
+		Name:     wrapper.Name(),
+		Kind:     wrapper.kind,
+		Duration: d,
+		Err:      err,
+		At:       time.Now(),
+	}
+	for _, hook := range hooks {
+		callLifecycleHook(hook, info)
+	}
+}
+
+// callLifecycleHook 执行单个回调并捕获其中的 panic，避免影响模块生命周期流程。
+func callLifecycleHook(hook LifecycleHook, info LifecycleInfo) {
+	defer func() {
+		if r := recover(); r != nil {
+			xlog.Errorf("module %s lifecycle hook %s panic recovered, panic %v\n%s", info.Name, info.Event, r, string(debug.Stack()))
+		}
+	}()
+	hook(info)
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventLog 并发安全地按模块记录生命周期事件。
+type eventLog struct {
+	mu     sync.Mutex
+	events map[string][]LifecycleInfo
+}
+
+func (l *eventLog) hook(info LifecycleInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events[info.Name] = append(l.events[info.Name], info)
+}
+
+// names 返回模块的事件名称序列；OnRun goroutine 中触发的 started 与加载流程中的事件先后不确定，
+// 校验其出现一次后从序列中移除。
+func (l *eventLog) names(t *testing.T, module string) []string {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var names []string
+	started := 0
+	for _, info := range l.events[module] {
+		if info.Event == EventStarted {
+			started++
+			continue
+		}
+		names = append(names, info.Event.String())
+	}
+	if started != 1 {
+		t.Errorf("module %s started %d times, want 1", module, started)
+	}
+	return names
+}
+
+// waitEvent 等待模块触发指定事件。
+func (l *eventLog) waitEvent(t *testing.T, module string, event LifecycleEvent) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.mu.Lock()
+		found := slices.ContainsFunc(l.events[module], func(info LifecycleInfo) bool { return info.Event == event })
+		l.mu.Unlock()
+		if found {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("module %s event %s not emitted", module, event)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	log := &eventLog{events: map[string][]LifecycleInfo{}}
+	app := NewApp()
+	// 前一个回调的 panic 不影响后续回调和生命周期流程
+	app.AddLifecycleHook(func(LifecycleInfo) { panic("hook") })
+	app.AddLifecycleHook(log.hook)
+
+	if !app.start(&testModule{name: "static"}) {
+		t.Fatal("start failed")
+	}
+	if err := app.AddDynamicModules(&testModule{name: "dynamic"}); err != nil {
+		t.Fatal(err)
+	}
+	log.waitEvent(t, "static", EventStarted)
+	log.waitEvent(t, "dynamic", EventStarted)
+	app.RemoveDynamicModule("dynamic")
+	app.stop()
+
+	if got, want := log.names(t, "static"), []string{"before_init", "after_init", "stopping", "destroyed"}; !slices.Equal(got, want) {
+		t.Errorf("static events = %v, want %v", got, want)
+	}
+	if got, want := log.names(t, "dynamic"), []string{"before_init", "after_init", "dynamic_added", "stopping", "destroyed", "dynamic_removed"}; !slices.Equal(got, want) {
+		t.Errorf("dynamic events = %v, want %v", got, want)
+	}
+
+	for _, info := range log.events["dynamic"] {
+		if info.Kind != "dynamic" {
+			t.Errorf("event %s kind = %s, want dynamic", info.Event, info.Kind)
+		}
+		if info.Event == EventStopping && !errors.Is(info.Err, ErrModuleRemoved) {
+			t.Errorf("stopping err = %v, want %v", info.Err, ErrModuleRemoved)
+		}
+	}
+	for _, info := range log.events["static"] {
+		if info.Event == EventStopping && !errors.Is(info.Err, ErrAppShutdown) {
+			t.Errorf("stopping err = %v, want %v", info.Err, ErrAppShutdown)
+		}
+	}
+}
+
+func TestLifecycleInfoJSON(t *testing.T) {
+	data, err := json.Marshal(LifecycleInfo{Event: EventStopping, Name: "m", Err: ErrAppShutdown})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["error"] != ErrAppShutdown.Error() {
+		t.Errorf("marshal = %s, want error text", data)
+	}
+}
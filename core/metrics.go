@@ -0,0 +1,254 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+// IMetricsReporter 模块运行时指标上报接口，模块可选实现，用于补充框架无法直接获取的指标。
+//
+// 框架仅能通过 IModule.ChanRPC 获取服务端队列指标，客户端异步调用和定时器等指标
+// 由模块自行填充。Skeleton 已实现此接口，内嵌 Skeleton 的模块无需额外处理。
+// 该方法由监控 goroutine 调用，实现方只能读取并发安全的数据。
+type IMetricsReporter interface {
+	ReportMetrics(m *ModuleMetrics)
+}
+
+// ModuleMetrics 单个模块的结构化指标快照。
+type ModuleMetrics struct {
+	Name         string                 `json:"name"`          // 模块名称
+	Kind         string                 `json:"kind"`          // 模块类型：static 或 dynamic
+	Server       *chanrpc.ServerMetrics `json:"server"`        // ChanRPC 服务端指标，模块不支持 RPC 时为 nil
+	Client       *chanrpc.ClientMetrics `json:"client"`        // ChanRPC 客户端指标，由 IMetricsReporter 填充，未上报时为 nil
+	ActiveTimers int64                  `json:"active_timers"` // 活跃定时器数量，由 IMetricsReporter 填充，未上报时为 -1
+	Status       string                 `json:"status"`        // 运行状态：idle、running、restarting、dead 或 stopped
+	Restarts     int64                  `json:"restarts"`      // 监督策略累计重启次数
+	LockedThread bool                   `json:"locked_thread"` // OnRun goroutine 是否独占系统线程
+}
+
+// Metrics 返回所有模块（静态 + 动态）的结构化指标快照，静态模块在前，动态模块在后。
+func (a *App) Metrics() []ModuleMetrics {
+	var res []ModuleMetrics
+
+	a.RLock()
+	for _, wrapper := range a.modules {
+		res = append(res, a.moduleMetrics("static", wrapper))
+	}
+	a.RUnlock()
+
+	a.dynamicModules.Range(func(key, value any) bool {
+		if wrapper, ok := value.(*moduleWrapper); ok {
+			res = append(res, a.moduleMetrics("dynamic", wrapper))
+		}
+		return true
+	})
+	return res
+}
+
+// moduleMetrics 采集单个模块的指标快照。
+func (a *App) moduleMetrics(kind string, wrapper *moduleWrapper) ModuleMetrics {
+	m := ModuleMetrics{
+		Name:         wrapper.Name(),
+		Kind:         kind,
+		ActiveTimers: -1,
+		Status:       moduleStatus(wrapper.status.Load()).String(),
+		Restarts:     wrapper.restarts.Load(),
+		LockedThread: wrapper.lockedThread.Load(),
+	}
+	if server := wrapper.ChanRPC(); server != nil {
+		sm := server.Metrics()
+		m.Server = &sm
+	}
+	if reporter, ok := wrapper.IModule.(IMetricsReporter); ok {
+		reporter.ReportMetrics(&m)
+	}
+	return m
+}
+
+// WritePrometheus 以 Prometheus 文本暴露格式（text/plain; version=0.0.4）输出所有模块的指标。
+//
+// 指标均以 core_ 为前缀，并携带 module、kind 标签；Handler 相关指标额外携带 message_id、message 标签：
+//   - core_module_chan_call_length / core_module_chan_call_capacity：全部优先级通道的积压与容量
+//   - core_module_chan_call_lane_length：各优先级通道的积压，额外携带 priority 标签
+//   - core_module_backpressure_total：各背压事件（rejected、blocked、timeout、dropped、spilled、high_water）的次数，额外携带 policy、event 标签
+//   - core_module_async_ret_length / core_module_async_ret_capacity：ChanAsyncRet 积压与容量
+//   - core_module_pending_async_calls：尚未执行回调的异步调用数量
+//   - core_module_active_timers：活跃定时器数量
+//   - core_module_handler_panics_total / core_module_callback_panics_total：panic 次数
+//   - core_module_abandoned_calls_total：出队时调用方已超时或取消而被丢弃的调用次数
+//   - core_module_coalesced_calls_total：合并到相同在途请求而未入队的调用次数
+//   - core_module_batches_total：批量处理函数的执行次数
+//   - core_module_restarts_total：动态模块被监督策略重启的次数
+//   - core_module_up：模块 OnRun 是否正在运行（1/0），dead 模块为 0
+//   - core_module_locked_thread：模块是否独占系统线程（1/0）
+//   - core_threads_locked / core_threads_created：独占线程总数与进程累计创建的系统线程数（无标签）
+//   - core_chanrpc_handler_duration_seconds：Handler 执行耗时直方图
+func (a *App) WritePrometheus(w io.Writer) error {
+	metrics := a.Metrics()
+	bw := bufio.NewWriter(w)
+
+	// family 输出一个模块级指标族，value 返回 false 表示该模块不具备此指标
+	family := func(typ, name, help string, value func(m *ModuleMetrics) (float64, bool)) {
+		writePromHeader(bw, name, help, typ)
+		for i := range metrics {
+			if v, ok := value(&metrics[i]); ok {
+				writePromSample(bw, name, moduleLabels(&metrics[i]), v)
+			}
+		}
+	}
+
+	family("gauge", "core_module_chan_call_length", "Number of calls queued in module priority lanes.", func(m *ModuleMetrics) (float64, bool) {
+		return serverValue(m, func(sm *chanrpc.ServerMetrics) float64 { return float64(sm.QueueLen) })
+	})
+	family("gauge", "core_module_chan_call_capacity", "Total capacity of module priority lanes.", func(m *ModuleMetrics) (float64, bool) {
+		return serverValue(m, func(sm *chanrpc.ServerMetrics) float64 { return float64(sm.QueueCap) })
+	})
+	writePromHeader(bw, "core_module_chan_call_lane_length", "Number of calls queued in each module priority lane.", "gauge")
+	for i := range metrics {
+		m := &metrics[i]
+		if m.Server == nil {
+			continue
+		}
+		for _, lm := range m.Server.Lanes {
+			writePromSample(bw, "core_module_chan_call_lane_length", moduleLabels(m)+`,priority="`+lm.Priority+`"`, float64(lm.Len))
+		}
+	}
+	writePromHeader(bw, "core_module_backpressure_total", "Total backpressure events of module priority lanes.", "counter")
+	for i := range metrics {
+		m := &metrics[i]
+		if m.Server == nil {
+			continue
+		}
+		bm := &m.Server.Backpressure
+		labels := moduleLabels(m) + `,policy="` + bm.Policy + `",event="`
+		for _, e := range []struct {
+			event string
+			value uint64
+		}{
+			{"rejected", bm.Rejected},
+			{"blocked", bm.Blocked},
+			{"timeout", bm.Timeouts},
+			{"dropped", bm.Dropped},
+			{"spilled", bm.Spilled},
+			{"high_water", bm.HighWater},
+		} {
+			writePromSample(bw, "core_module_backpressure_total", labels+e.event+`"`, float64(e.value))
+		}
+	}
+	family("gauge", "core_module_async_ret_length", "Number of async results queued in module ChanAsyncRet.", func(m *ModuleMetrics) (float64, bool) {
+		return clientValue(m, func(cm *chanrpc.ClientMetrics) float64 { return float64(cm.AsyncRetLen) })
+	})
+	family("gauge", "core_module_async_ret_capacity", "Capacity of module ChanAsyncRet.", func(m *ModuleMetrics) (float64, bool) {
+		return clientValue(m, func(cm *chanrpc.ClientMetrics) float64 { return float64(cm.AsyncRetCap) })
+	})
+	family("gauge", "core_module_pending_async_calls", "Number of async calls waiting for callback.", func(m *ModuleMetrics) (float64, bool) {
+		return clientValue(m, func(cm *chanrpc.ClientMetrics) float64 { return float64(cm.Pending) })
+	})
+	family("gauge", "core_module_active_timers", "Number of active timers.", func(m *ModuleMetrics) (float64, bool) {
+		return float64(m.ActiveTimers), m.ActiveTimers >= 0
+	})
+	family("counter", "core_module_handler_panics_total", "Total panics recovered in chanrpc handlers.", func(m *ModuleMetrics) (float64, bool) {
+		return serverValue(m, func(sm *chanrpc.ServerMetrics) float64 { return float64(sm.Panics) })
+	})
+	family("counter", "core_module_abandoned_calls_total", "Total calls dropped because the caller had given up.", func(m *ModuleMetrics) (float64, bool) {
+		return serverValue(m, func(sm *chanrpc.ServerMetrics) float64 { return float64(sm.Abandoned) })
+	})
+	family("counter", "core_module_coalesced_calls_total", "Total calls coalesced into an identical in-flight call.", func(m *ModuleMetrics) (float64, bool) {
+		return serverValue(m, func(sm *chanrpc.ServerMetrics) float64 { return float64(sm.Coalesced) })
+	})
+	family("counter", "core_module_batches_total", "Total batch handler executions.", func(m *ModuleMetrics) (float64, bool) {
+		return serverValue(m, func(sm *chanrpc.ServerMetrics) float64 { return float64(sm.Batches) })
+	})
+	family("counter", "core_module_callback_panics_total", "Total panics recovered in async callbacks.", func(m *ModuleMetrics) (float64, bool) {
+		return clientValue(m, func(cm *chanrpc.ClientMetrics) float64 { return float64(cm.CallbackPanics) })
+	})
+
+	family("counter", "core_module_restarts_total", "Total restarts of the module by its supervisor.", func(m *ModuleMetrics) (float64, bool) {
+		return float64(m.Restarts), true
+	})
+	family("gauge", "core_module_up", "Whether the module run loop is running.", func(m *ModuleMetrics) (float64, bool) {
+		if m.Status == moduleStatusRunning.String() {
+			return 1, true
+		}
+		return 0, true
+	})
+
+	family("gauge", "core_module_locked_thread", "Whether the module run loop is locked to an OS thread.", func(m *ModuleMetrics) (float64, bool) {
+		if m.LockedThread {
+			return 1, true
+		}
+		return 0, true
+	})
+
+	threads := a.Threads()
+	writePromHeader(bw, "core_threads_locked", "Number of OS threads locked by module run loops.", "gauge")
+	_, _ = fmt.Fprintf(bw, "core_threads_locked %d\n", threads.Locked)
+	writePromHeader(bw, "core_threads_created", "Number of OS threads created by the process.", "gauge")
+	_, _ = fmt.Fprintf(bw, "core_threads_created %d\n", threads.Created)
+
+	const histName = "core_chanrpc_handler_duration_seconds"
+	writePromHeader(bw, histName, "Execution time of chanrpc handlers.", "histogram")
+	for i := range metrics {
+		m := &metrics[i]
+		if m.Server == nil {
+			continue
+		}
+		for _, hm := range m.Server.Handlers {
+			labels := moduleLabels(m) + `,message_id="` + strconv.FormatUint(uint64(hm.MessageID), 10) +
+				`",message="` + escapePromLabel(hm.Message) + `"`
+			for b, bound := range chanrpc.LatencyBuckets {
+				le := strconv.FormatFloat(bound.Seconds(), 'g', -1, 64)
+				writePromSample(bw, histName+"_bucket", labels+`,le="`+le+`"`, float64(hm.Buckets[b]))
+			}
+			writePromSample(bw, histName+"_bucket", labels+`,le="+Inf"`, float64(hm.Count))
+			writePromSample(bw, histName+"_sum", labels, hm.Sum.Seconds())
+			writePromSample(bw, histName+"_count", labels, float64(hm.Count))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// serverValue 从模块的服务端指标中取值，模块不支持 RPC 时返回 false。
+func serverValue(m *ModuleMetrics, f func(sm *chanrpc.ServerMetrics) float64) (float64, bool) {
+	if m.Server == nil {
+		return 0, false
+	}
+	return f(m.Server), true
+}
+
+// clientValue 从模块的客户端指标中取值，模块未上报客户端指标时返回 false。
+func clientValue(m *ModuleMetrics, f func(cm *chanrpc.ClientMetrics) float64) (float64, bool) {
+	if m.Client == nil {
+		return 0, false
+	}
+	return f(m.Client), true
+}
+
+// moduleLabels 构造模块级指标的公共标签。
+func moduleLabels(m *ModuleMetrics) string {
+	return `module="` + escapePromLabel(m.Name) + `",kind="` + m.Kind + `"`
+}
+
+// writePromHeader 输出指标的 HELP 和 TYPE 注释行。
+func writePromHeader(w *bufio.Writer, name, help, typ string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// writePromSample 输出一条指标样本。
+func writePromSample(w *bufio.Writer, name, labels string, value float64) {
+	_, _ = fmt.Fprintf(w, "%s{%s} %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// promLabelReplacer 按 Prometheus 文本格式要求转义标签值中的反斜杠、双引号和换行。
+var promLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapePromLabel 转义 Prometheus 标签值。
+func escapePromLabel(s string) string {
+	return promLabelReplacer.Replace(s)
+}
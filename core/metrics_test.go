@@ -0,0 +1,103 @@
+package core
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+type metricsReq struct{}
+
+func TestWritePrometheus(t *testing.T) {
+	app := NewApp()
+	mod := &skeletonModule{NewSkeleton(`a"b\c`)}
+	_ = mod.RegisterChanRPC(&metricsReq{}, func(*chanrpc.CallInfo) *chanrpc.RetInfo { return nil })
+	app.modules = append(app.modules, app.newModuleWrapper("static", mod))
+
+	s := mod.ChanRPC()
+	c := chanrpc.NewClient(1)
+	for range 3 {
+		c.Cast(s, &metricsReq{})
+	}
+	for ci := s.Poll(); ci != nil; ci = s.Poll() {
+		s.Exec(ci)
+	}
+
+	var buf bytes.Buffer
+	if err := app.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# HELP core_module_up Whether the module run loop is running.\n# TYPE core_module_up gauge\n",
+		"# TYPE core_module_restarts_total counter\n",
+		"# TYPE core_chanrpc_handler_duration_seconds histogram\n",
+		`core_module_up{module="a\"b\\c",kind="static"} 0` + "\n",
+		"core_threads_locked 0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+
+	// 每条样本都属于已声明 TYPE 的指标族，直方图样本带 _bucket、_sum、_count 后缀
+	types := map[string]string{}
+	var buckets []float64
+	var sum, count string
+	for line := range strings.Lines(out) {
+		line = strings.TrimSuffix(line, "\n")
+		if f := strings.Fields(line); len(f) == 4 && f[1] == "TYPE" {
+			types[f[2]] = f[3]
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, _, _ := strings.Cut(line, "{")
+		name, _, _ = strings.Cut(name, " ")
+		family := name
+		for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+			if base, ok := strings.CutSuffix(name, suffix); ok && types[base] == "histogram" {
+				family = base
+			}
+		}
+		if _, ok := types[family]; !ok {
+			t.Errorf("sample %q without TYPE header", line)
+		}
+
+		value := line[strings.LastIndexByte(line, ' ')+1:]
+		switch name {
+		case "core_chanrpc_handler_duration_seconds_bucket":
+			v, _ := strconv.ParseFloat(value, 64)
+			buckets = append(buckets, v)
+		case "core_chanrpc_handler_duration_seconds_sum":
+			sum = value
+		case "core_chanrpc_handler_duration_seconds_count":
+			count = value
+		}
+	}
+
+	if len(buckets) != len(chanrpc.LatencyBuckets)+1 {
+		t.Fatalf("buckets = %v, want %d including +Inf", buckets, len(chanrpc.LatencyBuckets)+1)
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] < buckets[i-1] {
+			t.Errorf("buckets = %v, want cumulative", buckets)
+			break
+		}
+	}
+	if buckets[len(buckets)-1] != 3 || count != "3" {
+		t.Errorf("+Inf bucket %v count %s, want 3", buckets[len(buckets)-1], count)
+	}
+	if v, err := strconv.ParseFloat(sum, 64); err != nil || v <= 0 {
+		t.Errorf("sum = %q, want positive", sum)
+	}
+	if !strings.Contains(out, `core_chanrpc_handler_duration_seconds_bucket{module="a\"b\\c",kind="static",message_id="`) ||
+		!strings.Contains(out, `message="*core.metricsReq",le="+Inf"} 3`) {
+		t.Error("histogram labels missing")
+	}
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+// 模块停止原因，作为 OnRun ctx 的取消原因（context.Cause）传递给模块。
+//
+// 模块可在 ctx.Done() 后通过 StopCause 或 errors.Is 判断停止原因，据此选择不同的持久化策略，
+// 如应用关闭时全量落盘、动态卸载时仅保存增量、依赖模块故障时放弃未完成的事务。
+var (
+	ErrAppShutdown       = errors.New("core: application shutdown")           // 应用优雅关闭（SIGINT/SIGTERM）
+	ErrModuleRemoved     = errors.New("core: dynamic module removed")         // 动态模块被 RemoveDynamicModule 卸载或批量加载失败回滚
+	ErrSupervisorRestart = errors.New("core: module restarted by supervisor") // OnRun panic 后被监督策略重启，仅取消上一轮 OnRun 的 ctx
+	ErrParentFailed      = errors.New("core: parent module failed")           // 所依赖的动态模块 panic 后进入 dead 状态
+)
+
+// moduleCtxKey 模块上下文信息在 context 中的键，使用私有类型避免与其他包冲突。
+type moduleCtxKey struct{}
+
+// moduleInfo 模块上下文信息，随 OnRun 的 ctx 传递给模块。
+//
+// 子日志器延迟创建：模块包装在 Register 时即已创建，而业务通常在其后才调用 xlog.SetupLogger 初始化日志，
+// 延迟到首次使用时创建可保证子日志器派生自最终生效的根日志器。
+type moduleInfo struct {
+	name       string
+	app        *App
+	loggerOnce sync.Once
+	logger     xlog.ILogger
+}
+
+// newModuleContext 创建携带模块名称、子日志器和所属 App 的根上下文及其带原因的取消函数。
+func newModuleContext(a *App, name string) (context.Context, context.CancelCauseFunc) {
+	ctx := context.WithValue(context.Background(), moduleCtxKey{}, &moduleInfo{name: name, app: a})
+	return context.WithCancelCause(ctx)
+}
+
+// moduleInfoFrom 从 ctx 中取出模块上下文信息，ctx 不是模块上下文时返回 nil。
+func moduleInfoFrom(ctx context.Context) *moduleInfo {
+	info, _ := ctx.Value(moduleCtxKey{}).(*moduleInfo)
+	return info
+}
+
+// ModuleName 返回 ctx 所属模块的名称，ctx 不是由框架传入 OnRun 的上下文（或其派生上下文）时返回空字符串。
+func ModuleName(ctx context.Context) string {
+	if info := moduleInfoFrom(ctx); info != nil {
+		return info.name
+	}
+	return ""
+}
+
+// Logger 返回 ctx 所属模块的子日志器，日志自动携带 module 字段；ctx 不是模块上下文时返回全局日志器的子日志器。
+func Logger(ctx context.Context) xlog.ILogger {
+	info := moduleInfoFrom(ctx)
+	if info == nil {
+		return xlog.GetSubLogger()
+	}
+	info.loggerOnce.Do(func() {
+		info.logger = xlog.GetSubLoggerWithKeyValue(map[string]string{"module": info.name})
+	})
+	return info.logger
+}
+
+// AppFromContext 返回 ctx 所属模块注册的 App，ctx 不是模块上下文时返回 nil。
+func AppFromContext(ctx context.Context) *App {
+	if info := moduleInfoFrom(ctx); info != nil {
+		return info.app
+	}
+	return nil
+}
+
+// StopCause 返回模块停止的原因（ErrAppShutdown、ErrModuleRemoved 等），ctx 尚未取消时返回 nil。
+//
+// 与 context.Cause 等价，ErrParentFailed 会包装故障模块的名称，需使用 errors.Is 判断。
+func StopCause(ctx context.Context) error {
+	return context.Cause(ctx)
+}
+
+// failDependents 在动态模块进入 dead 状态后，以 ErrParentFailed 为原因停止直接或间接依赖它的动态模块。
+//
+// 被停止的模块 OnRun 正常退出后保留在动态模块集合中（状态为 stopped），需通过 RemoveDynamicModule 移除或重新加载。
+func (a *App) failDependents(failed string) {
+	queue := []string{failed}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		a.dynamicModules.Range(func(key, value any) bool {
+			wrapper, ok := value.(*moduleWrapper)
+			if !ok || wrapper.ctx.Err() != nil || !slices.Contains(moduleDependencies(wrapper), parent) {
+				return true
+			}
+			xlog.Errorf("module %s stopping because its dependency %s failed", wrapper.Name(), parent)
+			wrapper.cancel(fmt.Errorf("%w: %s", ErrParentFailed, failed))
+			queue = append(queue, wrapper.Name())
+			return true
+		})
+	}
+}
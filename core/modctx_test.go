@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stopRecorder 记录模块 OnRun 所见的 ctx 信息。
+type stopRecorder struct {
+	name  string
+	app   *App
+	cause error
+}
+
+// recordStop 返回在 ctx 结束后将 ctx 信息写入 ch 的 OnRun。
+func recordStop(ch chan<- stopRecorder) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		<-ctx.Done()
+		ch <- stopRecorder{name: ModuleName(ctx), app: AppFromContext(ctx), cause: StopCause(ctx)}
+	}
+}
+
+// waitStop 等待模块上报停止信息。
+func waitStop(t *testing.T, ch <-chan stopRecorder) stopRecorder {
+	t.Helper()
+	select {
+	case rec := <-ch:
+		return rec
+	case <-time.After(time.Second):
+		t.Fatal("module not stopped")
+		return stopRecorder{}
+	}
+}
+
+func TestStopCauseAppShutdown(t *testing.T) {
+	ch := make(chan stopRecorder, 1)
+	app := NewApp()
+	if !app.start(&testModule{name: "static", run: recordStop(ch)}) {
+		t.Fatal("start failed")
+	}
+	app.stop()
+
+	rec := waitStop(t, ch)
+	if rec.name != "static" || rec.app != app || !errors.Is(rec.cause, ErrAppShutdown) {
+		t.Errorf("OnRun saw name %q app %p cause %v, want static %p %v", rec.name, rec.app, rec.cause, app, ErrAppShutdown)
+	}
+}
+
+func TestStopCauseModuleRemoved(t *testing.T) {
+	ch := make(chan stopRecorder, 1)
+	app := NewApp()
+	if err := app.AddDynamicModules(&testModule{name: "dynamic", run: recordStop(ch)}); err != nil {
+		t.Fatal(err)
+	}
+	app.RemoveDynamicModule("dynamic")
+
+	rec := waitStop(t, ch)
+	if rec.name != "dynamic" || rec.app != app || !errors.Is(rec.cause, ErrModuleRemoved) {
+		t.Errorf("OnRun saw name %q cause %v, want dynamic %v", rec.name, rec.cause, ErrModuleRemoved)
+	}
+}
+
+// restartingModule 首轮 OnRun 记录 ctx 后 panic，此后的 OnRun 正常运行的受监督模块。
+type restartingModule struct {
+	testModule
+	runs     atomic.Int32
+	firstCtx chan context.Context
+}
+
+func (m *restartingModule) SupervisorSpec() SupervisorSpec {
+	return SupervisorSpec{Policy: RestartAlways}
+}
+
+func (m *restartingModule) OnRun(ctx context.Context) {
+	if m.runs.Add(1) == 1 {
+		m.firstCtx <- ctx
+		panic("crash")
+	}
+	<-ctx.Done()
+}
+
+func TestStopCauseSupervisorRestart(t *testing.T) {
+	mod := &restartingModule{testModule: testModule{name: "restarting"}, firstCtx: make(chan context.Context, 1)}
+	app := NewApp()
+	if err := app.AddDynamicModules(mod); err != nil {
+		t.Fatal(err)
+	}
+	defer app.removeAllDynamicModules(time.Time{})
+
+	ctx := <-mod.firstCtx
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("previous run ctx not canceled after restart")
+	}
+	if cause := StopCause(ctx); !errors.Is(cause, ErrSupervisorRestart) {
+		t.Errorf("previous run cause = %v, want %v", cause, ErrSupervisorRestart)
+	}
+	if ModuleName(ctx) != "restarting" {
+		t.Errorf("module name = %q, want restarting", ModuleName(ctx))
+	}
+}
+
+func TestStopCauseParentFailed(t *testing.T) {
+	release := make(chan struct{})
+	child, grandchild := make(chan stopRecorder, 1), make(chan stopRecorder, 1)
+	app := NewApp()
+	err := app.AddDynamicModules(
+		&testModule{name: "crash", run: func(context.Context) {
+			<-release
+			panic("crash")
+		}},
+		&testModule{name: "child", deps: []string{"crash"}, run: recordStop(child)},
+		&testModule{name: "grandchild", deps: []string{"child"}, run: recordStop(grandchild)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.removeAllDynamicModules(time.Time{})
+	close(release)
+
+	// 直接和间接依赖方均以包装了故障模块名称的 ErrParentFailed 停止
+	for _, ch := range []chan stopRecorder{child, grandchild} {
+		rec := waitStop(t, ch)
+		if !errors.Is(rec.cause, ErrParentFailed) || !strings.Contains(rec.cause.Error(), "crash") {
+			t.Errorf("module %s cause = %v, want %v of crash", rec.name, rec.cause, ErrParentFailed)
+		}
+	}
+}
+
+func TestModuleContextOutsideModule(t *testing.T) {
+	ctx := context.Background()
+	if ModuleName(ctx) != "" || AppFromContext(ctx) != nil || StopCause(ctx) != nil {
+		t.Error("plain context reported module information")
+	}
+}
@@ -2,11 +2,13 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
-	"runtime"
 	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -38,61 +40,118 @@ const (
 	AppStateStop        // 应用正在优雅关闭，模块正按逆序依次停止
 )
 
-const (
-	// defaultShutdownTimeout 单个模块优雅关闭的最大等待时间。
-	// 设置为 30 分钟是为了兼容可能持有长时间锁或大批量数据落盘的模块，
-	// 超时后记录错误日志但不强制终止，避免数据损坏，由运维介入处理。
-	defaultShutdownTimeout = 30 * time.Minute
-)
-
 // moduleWrapper 为 IModule 附加框架运行时所需的控制元数据。
 //
-// ctx/cancel 构成模块停止信号通道：框架通过调用 cancel 通知模块 OnStart 应退出主循环；
-// wg 用于等待模块 goroutine 完全退出后再调用 OnDestroy，保证资源清理的时序正确。
+// ctx/cancel 构成模块停止信号通道：框架通过调用 cancel 并附带停止原因（ErrAppShutdown 等）通知模块 OnStart 应退出主循环，
+// ctx 同时携带模块名称、子日志器和所属 App（见 ModuleName、Logger、AppFromContext）；
+// wg 用于等待模块 goroutine 完全退出后再调用 OnDestroy，保证资源清理的时序正确；
+// running 标记 OnRun goroutine 是否仍在运行，供健康检查判断模块存活性；
+// status、restarts 记录运行状态和监督重启次数，restartLog、restartStreak、restartAt 仅在模块 goroutine 中访问；
+// lockedThread 标记 OnRun goroutine 当前是否独占系统线程（见 IThreadLocked）。
 type moduleWrapper struct {
 	IModule
-	ctx    context.Context
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
+	ctx           context.Context
+	cancel        context.CancelCauseFunc
+	wg            sync.WaitGroup
+	running       atomic.Bool
+	status        atomic.Int32 // moduleStatus
+	restarts      atomic.Int64 // 监督策略累计重启次数
+	restartLog    []time.Time  // 统计窗口内的重启时间，按时间递增，仅在 SupervisorSpec.Window 大于 0 时记录
+	restartStreak int          // Window 不大于 0 时连续重启的次数，用于计算退避延迟
+	restartAt     time.Time    // Window 不大于 0 时最近一次重启 OnRun 的时间
+	lockedThread  atomic.Bool
+	removing      atomic.Bool // 动态模块已被某次 RemoveDynamicModule 认领移除，防止并发移除重复销毁
+	kind          string      // 模块类型：static 或 dynamic
 }
 
-// app 是应用框架的核心结构，管理静态模块列表和动态模块集合。
+// App 是应用框架的核心结构，管理静态模块列表和动态模块集合。
+//
+// 每个 App 实例拥有独立的模块注册表和生命周期，同一进程内可创建多个互不干扰的 App
+// （如并行测试、嵌入式场景）；包级函数均代理到默认实例，典型场景下直接使用 core.Run 即可。
+// 注册到 App 的 Skeleton 会自动绑定该实例，其 Cast/Call/AsyncCall 仅在所属 App 内寻址。
+// 隔离仅限于模块寻址和生命周期：chanrpc 的全局消息注册表（消息 ID → 类型，见 chanrpc.Messages）
+// 和同步调用的死锁检测等待图仍由进程内全部 App 共享，不同 App 中的类型同样不能发生消息 ID 碰撞。
 //
 // 并发安全设计：
 //   - modules 切片：应用启动后只读，RWMutex 的读锁保护并发读取，写锁在启动前注册时使用
 //   - dynamicModules：使用 sync.Map，原生支持动态模块的并发增删改查，无需额外锁
 //   - state：使用 atomic.Int32，保证跨 goroutine 的状态读写原子可见
-type app struct {
+type App struct {
 	sync.RWMutex
-	modules        []*moduleWrapper // 静态模块有序列表，按注册顺序启动，按逆序关闭
+	modules        []*moduleWrapper // 静态模块有序列表，启动前按依赖关系拓扑排序，按此顺序启动，按逆序关闭
 	dynamicModules sync.Map         // 动态模块集合（key: 模块名，value: *moduleWrapper），支持热加载
 	state          int32            // 应用全局状态，使用 atomic 操作确保并发可见性
+	initWorkers    atomic.Int32     // 模块 OnInit 的最大并发数，1 表示串行初始化
+	lockedThreads  atomic.Int64     // 当前被模块 goroutine 独占的系统线程数
+
+	healthInterval atomic.Int64                                     // 健康检查轮询周期（time.Duration）
+	health         atomic.Pointer[map[*moduleWrapper]checkerResult] // 最近一次轮询的 IHealthChecker 结果缓存
+	healthCancel   context.CancelFunc                               // 停止健康检查 goroutine，受 RWMutex 保护
+
+	shutdownBudget atomic.Int64 // 关闭流程总时间预算（time.Duration），0 表示不限制
+	shutdownPolicy atomic.Int32 // 模块关闭超时后的处理策略（ShutdownPolicy）
+
+	reloading     atomic.Bool                    // 是否正在执行重新加载，防止 SIGHUP 连续到达时并发重载
+	reloadResults atomic.Pointer[[]ReloadResult] // 最近一次重新加载的各模块结果
+
+	lifecycle lifecycleHooks  // 生命周期事件回调
+	caster    *chanrpc.Client // App 级 Broadcast/Multicast 使用的投递客户端，单向投递不使用其回包队列
 }
 
-// newApp 创建新的应用框架实例，初始状态为 AppStateNone。
-func newApp() *app {
-	return &app{
+// NewApp 创建新的应用框架实例，初始状态为 AppStateNone。
+func NewApp() *App {
+	a := &App{
 		state:   AppStateNone,
 		modules: make([]*moduleWrapper, 0),
+		caster:  chanrpc.NewClient(0),
+	}
+	a.initWorkers.Store(defaultInitWorkers)
+	a.healthInterval.Store(int64(defaultHealthCheckInterval))
+	return a
+}
+
+// SetInitWorkers 设置模块 OnInit 的最大并发数，须在 Run 之前调用。
+//
+// 互不依赖的模块（未通过 IDependent 建立依赖关系）将被并发初始化，适合加载大型 TSV 表、
+// 建立 AMQP 连接等耗时较长的初始化逻辑。n 小于 1 时按 1 处理，即串行初始化。
+func (a *App) SetInitWorkers(n int) {
+	if n < 1 {
+		n = 1
+	}
+	a.initWorkers.Store(int32(n))
+}
+
+// newModuleWrapper 为模块创建运行时包装，并将实现了 appBinder 的模块（如内嵌 Skeleton 的模块）绑定到当前 App。
+func (a *App) newModuleWrapper(kind string, mod IModule) *moduleWrapper {
+	if binder, ok := mod.(appBinder); ok {
+		binder.BindApp(a)
 	}
+	wrapper := &moduleWrapper{
+		IModule: mod,
+		kind:    kind,
+	}
+	wrapper.ctx, wrapper.cancel = newModuleContext(a, mod.Name())
+	return wrapper
 }
 
 // setState 通过原子写入更新应用状态，确保状态变更对所有 goroutine 立即可见。
-func (a *app) setState(state int32) {
+func (a *App) setState(state int32) {
 	atomic.StoreInt32(&a.state, state)
 }
 
 // GetState 通过原子读取获取应用当前状态，可在任意 goroutine 中安全调用。
-func (a *app) GetState() int32 {
+func (a *App) GetState() int32 {
 	return atomic.LoadInt32(&a.state)
 }
 
 // Stats 返回所有模块（静态 + 动态）的 RPC 队列积压状态统计字符串。
 //
-// 输出格式："{static|dynamic}: {模块名}, rpc_queue_length: {队列长度}"
+// 输出格式："{static|dynamic}: {模块名}, rpc_queue_length: {队列长度}, status: {运行状态}, restarts: {重启次数}, locked_thread: {是否独占线程}"
 // rpc_queue_length 反映模块消息积压程度，是性能瓶颈和消息处理速率的重要观测指标。
 // N/A 表示该模块未配置 ChanRPC 服务端（如纯定时器模块）。
-func (a *app) Stats() string {
+// status 为 dead 表示动态模块 panic 后未被重启，仍占用模块名称，需通过 RemoveDynamicModule 移除。
+// 末行输出系统线程使用情况："threads: locked {独占线程数}, created {累计创建线程数}"。
+func (a *App) Stats() string {
 	a.RLock()
 	defer a.RUnlock()
 
@@ -111,21 +170,22 @@ func (a *app) Stats() string {
 		return true
 	})
 
+	threads := a.Threads()
+	builder.WriteString(fmt.Sprintf("threads: locked %d, created %d\n", threads.Locked, threads.Created))
+
 	return builder.String()
 }
 
 // appendModuleStats 将单个模块的状态信息追加到 builder，内部实现复用。
-func (a *app) appendModuleStats(builder *strings.Builder, moduleType string, wrapper *moduleWrapper) {
-	rpcServer := wrapper.ChanRPC()
-
-	if rpcServer != nil {
-		channelLen := len(rpcServer.ChanCall)
-		builder.WriteString(fmt.Sprintf("%s: %s, rpc_queue_length: %d\n",
-			moduleType, wrapper.Name(), channelLen))
-	} else {
-		builder.WriteString(fmt.Sprintf("%s: %s, rpc_queue_length: N/A\n",
-			moduleType, wrapper.Name()))
+func (a *App) appendModuleStats(builder *strings.Builder, moduleType string, wrapper *moduleWrapper) {
+	queueLen := "N/A"
+	if rpcServer := wrapper.ChanRPC(); rpcServer != nil {
+		queueLen = strconv.Itoa(rpcServer.Len())
 	}
+
+	builder.WriteString(fmt.Sprintf("%s: %s, rpc_queue_length: %s, status: %s, restarts: %d, locked_thread: %t\n",
+		moduleType, wrapper.Name(), queueLen, moduleStatus(wrapper.status.Load()),
+		wrapper.restarts.Load(), wrapper.lockedThread.Load()))
 }
 
 // GetChanRPC 通过模块名获取对应模块的 ChanRPC 服务端，用于跨模块消息投递。
@@ -133,7 +193,7 @@ func (a *app) appendModuleStats(builder *strings.Builder, moduleType string, wra
 // 查找策略：优先从静态模块列表中查找（加读锁），未命中时再查找动态模块（无锁，sync.Map 保证安全）。
 // 两步查找分开处理的原因：静态模块列表需要锁，而 sync.Map 无需锁，
 // 分开可以在找到静态模块时尽早释放读锁，减少锁持有时间。
-func (a *app) GetChanRPC(name string) *chanrpc.Server {
+func (a *App) GetChanRPC(name string) *chanrpc.Server {
 	a.RLock()
 	for _, wrapper := range a.modules {
 		if wrapper.Name() == name {
@@ -146,8 +206,23 @@ func (a *app) GetChanRPC(name string) *chanrpc.Server {
 	return a.getChanRPCDynamic(name)
 }
 
+// hasModule 判断指定名称的模块（静态或动态）是否已注册，用于动态模块的依赖校验。
+func (a *App) hasModule(name string) bool {
+	a.RLock()
+	for _, wrapper := range a.modules {
+		if wrapper.Name() == name {
+			a.RUnlock()
+			return true
+		}
+	}
+	a.RUnlock()
+
+	_, ok := a.dynamicModules.Load(name)
+	return ok
+}
+
 // getChanRPCDynamic 从动态模块集合中查找 ChanRPC 服务端。
-func (a *app) getChanRPCDynamic(name string) *chanrpc.Server {
+func (a *App) getChanRPCDynamic(name string) *chanrpc.Server {
 	if value, ok := a.dynamicModules.Load(name); ok {
 		if wrapper, ok := value.(*moduleWrapper); ok {
 			return wrapper.ChanRPC()
@@ -160,17 +235,14 @@ func (a *app) getChanRPCDynamic(name string) *chanrpc.Server {
 //
 // 静态模块在应用整个生命周期中持续运行，不支持热卸载。
 // 若应用已处于运行或停止状态则返回错误，防止运行时并发修改 modules 切片引发数据竞争。
-func (a *app) Register(mods ...IModule) error {
+func (a *App) Register(mods ...IModule) error {
 	if a.GetState() != AppStateNone {
 		return fmt.Errorf("application is already running")
 	}
 
 	for _, mod := range mods {
 		a.Lock()
-		wrapper := &moduleWrapper{
-			IModule: mod,
-		}
-		wrapper.ctx, wrapper.cancel = context.WithCancel(context.Background())
+		wrapper := a.newModuleWrapper("static", mod)
 		a.modules = append(a.modules, wrapper)
 		a.Unlock()
 	}
@@ -181,9 +253,10 @@ func (a *app) Register(mods ...IModule) error {
 // Run 注册模块、启动应用，并阻塞至收到 SIGINT/SIGTERM 信号后执行优雅关闭。
 //
 // 模块启动在独立 goroutine 中进行，通过 errCh 将启动失败通知主 goroutine。
-// SIGHUP 信号不触发关闭（通常用于通知应用重新加载配置），
+// SIGHUP 信号不触发关闭，而是对实现了 IReloadable 的模块执行重新加载（见 Reload），
 // SIGINT/SIGTERM 触发优雅关闭流程，适合在 main 函数中直接调用。
-func (a *app) Run(mods ...IModule) {
+// 返回每个模块的关闭结果（动态模块在前，静态模块按关闭顺序在后），启动失败时返回 nil。
+func (a *App) Run(mods ...IModule) []ShutdownResult {
 	var errCh = make(chan bool, 1)
 	go func() {
 		if !a.start(mods...) {
@@ -198,13 +271,14 @@ func (a *app) Run(mods ...IModule) {
 		select {
 		case <-errCh:
 			xlog.Errorln("application failed to start")
-			return
+			return nil
 		case sig := <-signalChan:
 			xlog.Infof("received shutdown signal %s", sig)
 
-			// SIGHUP 通常用于热重载配置，不触发关闭流程
+			// SIGHUP 用于热重载配置，不触发关闭流程，由实现了 IReloadable 的模块各自重新加载
 			if sig == syscall.SIGHUP {
-				xlog.Infof("SIGHUP received, continuing operation")
+				xlog.Infof("SIGHUP received, reloading modules")
+				a.reloadOnSignal()
 				continue
 			}
 			goto STOP
@@ -212,20 +286,21 @@ func (a *app) Run(mods ...IModule) {
 
 	}
 STOP:
-	a.stop()
+	return a.stop()
 }
 
-// start 按顺序初始化并启动所有已注册的模块。
+// start 按依赖顺序初始化并启动所有已注册的模块。
 //
 // 执行流程：
 //  1. 状态检查，防止重复启动
 //  2. 将 Run 参数中的模块追加到 modules 列表（支持 Register + Run 两种注册方式）
-//  3. 依次调用 OnInit，任一失败则中止启动并返回 false
-//  4. 为每个模块启动独立 goroutine 并运行 OnStart
+//  3. 按 IDependent 声明的依赖关系拓扑排序，名称重复、依赖缺失或存在环时中止启动
+//  4. 按依赖关系调用 OnInit（可按 SetInitWorkers 并发），任一失败则回滚已初始化模块并返回 false
+//  5. 为每个模块启动独立 goroutine 并运行 OnStart
 //
 // 顶层 panic recover：捕获启动过程中的意外 panic，记录完整堆栈后以退出码 255 终止进程，
 // 防止进程在不确定状态下继续运行造成数据损坏。
-func (a *app) start(mods ...IModule) bool {
+func (a *App) start(mods ...IModule) bool {
 	defer func() {
 		if r := recover(); r != nil {
 			xlog.Errorf("application panic recovered, panic %v\n%s", r, string(debug.Stack()))
@@ -244,10 +319,7 @@ func (a *app) start(mods ...IModule) bool {
 			xlog.Warnln("application cannot register nil module")
 			continue
 		}
-		wrapper := &moduleWrapper{
-			IModule: mod,
-		}
-		wrapper.ctx, wrapper.cancel = context.WithCancel(context.Background())
+		wrapper := a.newModuleWrapper("static", mod)
 		a.modules = append(a.modules, wrapper)
 	}
 	a.Unlock()
@@ -257,18 +329,28 @@ func (a *app) start(mods ...IModule) bool {
 		return false
 	}
 
+	// 按 IDependent 声明的依赖关系重排模块顺序，后续初始化、启动和逆序关闭均以此为准
+	a.Lock()
+	sorted, err := sortModules(a.modules, nil)
+	if err != nil {
+		a.Unlock()
+		xlog.Errorf("application module dependency resolve failed, err %v", err)
+		return false
+	}
+	a.modules = sorted
+	a.Unlock()
+
 	a.setState(AppStateInit)
 	xlog.Infof("application starting, module count: %d", len(a.modules))
 	for _, wrapper := range a.modules {
 		xlog.Infof("module startup order %s", wrapper.Name())
 	}
 
-	// 按注册顺序依次初始化，保证模块间的启动依赖关系（被依赖模块先初始化）
-	for _, wrapper := range a.modules {
-		if err := wrapper.OnInit(); err != nil {
-			xlog.Errorf("module %s initialization failed, err %v", wrapper.Name(), err)
-			return false
-		}
+	// 按依赖关系初始化，被依赖模块先于依赖它的模块初始化，互不依赖的模块可并发初始化；
+	// 任一失败时已初始化成功的模块会被逆序销毁，不会残留半初始化状态
+	if err := a.initModules(a.modules); err != nil {
+		xlog.Errorf("application initialization failed, err %v", err)
+		return false
 	}
 
 	// 所有模块初始化完成后，并发启动各自的 goroutine
@@ -278,119 +360,167 @@ func (a *app) start(mods ...IModule) bool {
 	}
 
 	a.setState(AppStateRun)
+
+	// 进入运行状态后启动健康检查，Health 的就绪性还取决于各模块主循环是否真正开始服务
+	healthCtx, healthCancel := context.WithCancel(context.Background())
+	a.Lock()
+	a.healthCancel = healthCancel
+	a.Unlock()
+	go a.runHealthCheck(healthCtx)
+
 	xlog.Infof("application started successfully")
 	return true
 }
 
 // onRunModule 在独立 goroutine 中运行模块的 OnStart 主循环。
 //
-// runtime.LockOSThread 将 goroutine 绑定到专用系统线程：
+// 实现了 IThreadLocked 并声明需要绑定线程的模块，通过 runtime.LockOSThread 将 goroutine 绑定到专用系统线程：
 //   - 保证某些依赖线程本地状态的库（如 OpenGL、部分 CGO 库）能正常工作
-//   - 代价是增加系统线程数，对纯 Go 模块而言可考虑移除此调用以减少线程开销
+//   - 代价是每个模块占用一个系统线程，因此默认不绑定，纯 Go 模块由调度器自由调度
 //
 // panic 处理策略差异：
 //   - 静态模块（dynamic=false）panic 后调用 os.Exit(255)，确保进程不在不确定状态下运行
-//   - 动态模块（dynamic=true）panic 仅记录日志，不影响其他模块和进程的正常运行
-func (a *app) onRunModule(wrapper *moduleWrapper, dynamic bool) {
-	runtime.LockOSThread()
+//   - 动态模块（dynamic=true）panic 不影响其他模块和进程的正常运行，按 ISupervised 声明的策略
+//     在同一 goroutine 中重新运行 OnRun；不重启时模块进入 dead 状态，仍保留在动态模块集合中
+func (a *App) onRunModule(wrapper *moduleWrapper, dynamic bool) {
+	locked := a.lockModuleThread(wrapper)
 	defer func() {
-		runtime.UnlockOSThread()
+		if locked {
+			a.unlockModuleThread(wrapper)
+		}
 		wrapper.wg.Done()
+	}()
+
+	for {
+		// 每轮 OnRun 使用独立的子 ctx，重启时以 ErrSupervisorRestart 取消上一轮派生的 goroutine
+		runCtx, runCancel := context.WithCancelCause(wrapper.ctx)
+		panicked := a.runModule(runCtx, wrapper)
+		if panicked == nil {
+			runCancel(nil)
+			return
+		}
+		if !dynamic {
+			os.Exit(255)
+		}
+
+		delay, restart := superviseRestart(wrapper, panicked)
+		if !restart {
+			runCancel(fmt.Errorf("module %s panic: %v", wrapper.Name(), panicked))
+			wrapper.status.Store(int32(moduleStatusDead))
+			xlog.Errorf("module %s is dead and will not be restarted", wrapper.Name())
+			if wrapper.ctx.Err() == nil {
+				a.failDependents(wrapper.Name())
+			}
+			return
+		}
+		runCancel(ErrSupervisorRestart)
+
+		xlog.Infof("module %s will restart in %s", wrapper.Name(), delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-wrapper.ctx.Done():
+			// 等待重启期间模块被移除或应用关闭，不再重启
+			timer.Stop()
+			wrapper.status.Store(int32(moduleStatusStopped))
+			return
+		case <-timer.C:
+		}
+		wrapper.restarts.Add(1)
+	}
+}
+
+// runModule 以 ctx 运行一次模块的 OnRun，返回其中 recover 得到的 panic 值，正常退出时返回 nil。
+func (a *App) runModule(ctx context.Context, wrapper *moduleWrapper) (panicked any) {
+	wrapper.running.Store(true)
+	wrapper.status.Store(int32(moduleStatusRunning))
+	start := time.Now()
+	defer func() {
+		wrapper.running.Store(false)
 		if r := recover(); r != nil {
 			xlog.Errorf("module %s panic recovered, panic %v\n%s", wrapper.Name(), r, string(debug.Stack()))
-			if !dynamic {
-				os.Exit(255)
-			}
+			wrapper.status.Store(int32(moduleStatusRestarting))
+			panicked = r
+			a.emit(EventPanicked, wrapper, time.Since(start), fmt.Errorf("panic: %v", r))
+			return
 		}
+		wrapper.status.Store(int32(moduleStatusStopped))
 	}()
 
 	xlog.Infof("started module %s", wrapper.Name())
+	a.emit(EventStarted, wrapper, 0, nil)
 
-	wrapper.OnRun(wrapper.ctx)
+	wrapper.OnRun(ctx)
 	xlog.Infof("module %s stopped", wrapper.Name())
+	return nil
 }
 
-// stop 按逆序优雅关闭所有模块，保证依赖关系正确解除。
+// stop 按逆依赖顺序优雅关闭所有模块，保证依赖关系正确解除。
 //
 // 关闭顺序设计：
 //  1. 先关闭所有动态模块（依赖于静态模块，故先于静态模块关闭）
-//  2. 再按静态模块的逆启动顺序（LIFO）关闭，后启动的先关闭
+//  2. 再按静态模块启动顺序（即拓扑排序结果）的逆序关闭，依赖方先于被依赖方关闭
 //
 // 逆序关闭保证了"被依赖模块（先启动）在依赖它的模块（后启动）完全停止后才销毁"的时序，
 // 避免在销毁时访问已销毁模块的资源。
-func (a *app) stop() {
+//
+// 所有模块共享 SetShutdownBudget 设置的总预算，返回每个模块的关闭结果。
+func (a *App) stop() []ShutdownResult {
 	if a.GetState() == AppStateStop {
 		xlog.Warnf("application already stopping")
-		return
+		return nil
 	}
 
 	a.setState(AppStateStop)
 	xlog.Infof("application shutdown initiated")
 
+	a.Lock()
+	if a.healthCancel != nil {
+		a.healthCancel()
+		a.healthCancel = nil
+	}
+	a.Unlock()
+
+	deadline := a.shutdownDeadline()
+
 	// 先关闭动态模块，它们通常依赖静态模块提供的服务
-	a.removeAllDynamicModules()
+	results := a.removeAllDynamicModules(deadline)
 
-	// 按逆序关闭静态模块，保证依赖关系正确解除（后启动的先关闭）
+	// 按启动顺序的逆序关闭静态模块，保证依赖方先于被依赖方关闭
 	a.RLock()
 	moduleCount := len(a.modules)
 	a.RUnlock()
 
 	for i := moduleCount - 1; i >= 0; i-- {
-		a.shutdownModule(a.modules[i])
+		results = append(results, a.shutdownModule("static", a.modules[i], deadline))
 	}
 
 	a.setState(AppStateNone)
 	xlog.Infof("application shutdown complete")
-}
-
-// shutdownModule 优雅关闭单个模块，完整流程为：发送停止信号 → 等待 goroutine 退出（含超时保护）→ 调用 OnDestroy。
-//
-// 超时保护通过独立 goroutine + done channel 实现，而非直接阻塞，
-// 原因是 wg.Wait 本身不支持超时，需要借助 select 和 timer 组合。
-// 超时后不强制退出，仅记录错误，因为强制终止可能导致数据损坏（如正在写数据库）。
-func (a *app) shutdownModule(wrapper *moduleWrapper) {
-	xlog.Infof("signaling module %s shutdown", wrapper.Name())
-	wrapper.cancel() // 通过 context 取消向模块的 OnStart 发送停止信号
-
-	// 在辅助 goroutine 中等待模块退出，配合 select + timer 实现超时保护
-	done := make(chan struct{})
-	go func() {
-		wrapper.wg.Wait()
-		close(done)
-	}()
-
-	timer := time.NewTimer(defaultShutdownTimeout)
-	defer timer.Stop()
-	select {
-	case <-done:
-		xlog.Infof("module %s goroutine exited", wrapper.Name())
-	case <-timer.C:
-		xlog.Errorf("module %s shutdown timeout", wrapper.Name())
-	}
-
-	xlog.Infof("destroying module %s", wrapper.Name())
-	a.destroyModule(wrapper)
-
-	xlog.Infof("module %s shutdown complete", wrapper.Name())
+	return results
 }
 
 // destroyModule 调用模块的 OnDestroy 并捕获其中可能发生的 panic。
 //
 // 防御性 panic 捕获的必要性：在关闭流程中，部分资源可能已半释放，
 // 若某模块的 OnDestroy 因访问已释放资源而 panic，必须隔离该 panic，
-// 确保其他模块的关闭流程不受影响，避免资源泄漏。
-func (a *app) destroyModule(wrapper *moduleWrapper) {
+// 确保其他模块的关闭流程不受影响，避免资源泄漏。panic 会被转换为错误返回，供关闭结果报告使用。
+// 无论是否 panic，返回前都会触发 EventDestroyed。
+func (a *App) destroyModule(wrapper *moduleWrapper) (err error) {
+	start := time.Now()
 	defer func() {
 		if r := recover(); r != nil {
 			xlog.Errorf("module %s destroy panic recovered, panic %v\n%s", wrapper.Name(), r, string(debug.Stack()))
+			err = fmt.Errorf("module %s destroy panic: %v", wrapper.Name(), r)
 		}
+		a.emit(EventDestroyed, wrapper, time.Since(start), err)
 	}()
 
 	wrapper.OnDestroy()
+	return nil
 }
 
 // DynamicModules 返回当前所有动态模块的名称列表，用于监控和管理。
-func (a *app) DynamicModules() (res []string) {
+func (a *App) DynamicModules() (res []string) {
 	a.dynamicModules.Range(func(key, value any) bool {
 		res = append(res, key.(string))
 		return true
@@ -401,80 +531,209 @@ func (a *app) DynamicModules() (res []string) {
 // AddDynamicModules 在运行时动态添加并启动一批模块，支持热加载。
 //
 // 与静态模块相比，动态模块的特殊之处：
-//   - panic 不会导致进程退出，仅记录日志（onStartModule 的 dynamic=true 参数控制）
+//   - panic 不会导致进程退出，按 ISupervised 声明的策略重启或进入 dead 状态（onRunModule 的 dynamic=true 参数控制）
 //   - 支持通过 RemoveDynamicModule 单独卸载，不影响其他模块
-//   - 模块按传入顺序依次初始化，任一失败则停止并返回错误（已成功初始化的模块不自动回滚）
-func (a *app) AddDynamicModules(mods ...IModule) error {
-	var wrappers []*moduleWrapper
-	for _, mod := range mods {
-		if mod == nil {
-			xlog.Warnln("application cannot register nil module")
-			continue
-		}
-		wrapper := &moduleWrapper{
-			IModule: mod,
-		}
-		wrapper.ctx, wrapper.cancel = context.WithCancel(context.Background())
-		wrappers = append(wrappers, wrapper)
+//   - 模块按依赖关系排序后依次初始化，依赖可指向本批次内模块或已运行的静态/动态模块
+//   - 任一失败则停止并返回错误（已成功初始化的模块不自动回滚，需要回滚请使用 AddDynamicModulesAtomic）
+func (a *App) AddDynamicModules(mods ...IModule) error {
+	wrappers, err := a.prepareDynamicModules(mods)
+	if err != nil {
+		return err
 	}
 
 	for _, wrapper := range wrappers {
-		if err := wrapper.OnInit(); err != nil {
+		start := time.Now()
+		if err := a.initModule(wrapper); err != nil {
 			xlog.Errorf("module %s init error %v", wrapper.Name(), err)
 			return fmt.Errorf("module %s init failed: %w", wrapper.Name(), err)
 		}
 		wrapper.wg.Add(1)
 		go a.onRunModule(wrapper, true) // dynamic=true：panic 不会退出进程
 		a.dynamicModules.Store(wrapper.Name(), wrapper)
+		a.emit(EventDynamicAdded, wrapper, time.Since(start), nil)
 	}
 	return nil
 }
 
-// RemoveDynamicModule 同步移除并销毁指定名称的动态模块。
+// AddDynamicModulesAtomic 以"全部成功或全部回滚"的语义动态添加并启动一批模块。
+//
+// 与 AddDynamicModules 的区别在于失败处理：
+//   - 启动前校验模块名称，与已注册的静态/动态模块重名时直接返回错误，不执行任何 OnInit
+//   - 任一模块 OnInit 失败（或启动期间出现同名模块）时，按逆启动顺序对本批次已启动的模块
+//     依次执行 cancel → wg.Wait → OnDestroy → 从 dynamicModules 移除，再返回错误
+//
+// 因此函数返回时，要么整批模块全部运行，要么应用的动态模块集合与调用前完全一致。
+func (a *App) AddDynamicModulesAtomic(mods ...IModule) error {
+	wrappers, err := a.prepareDynamicModules(mods)
+	if err != nil {
+		return err
+	}
+
+	for _, wrapper := range wrappers {
+		if a.hasModule(wrapper.Name()) {
+			return fmt.Errorf("module %s already exists", wrapper.Name())
+		}
+	}
+
+	var started []*moduleWrapper
+	for _, wrapper := range wrappers {
+		start := time.Now()
+		if err = a.initModule(wrapper); err != nil {
+			xlog.Errorf("module %s init error %v", wrapper.Name(), err)
+			err = fmt.Errorf("module %s init failed: %w", wrapper.Name(), err)
+			break
+		}
+		// LoadOrStore 防止校验之后、启动之前有并发调用注册了同名模块
+		if _, loaded := a.dynamicModules.LoadOrStore(wrapper.Name(), wrapper); loaded {
+			a.destroyModule(wrapper)
+			err = fmt.Errorf("module %s already exists", wrapper.Name())
+			break
+		}
+		wrapper.wg.Add(1)
+		go a.onRunModule(wrapper, true) // dynamic=true：panic 不会退出进程
+		started = append(started, wrapper)
+		a.emit(EventDynamicAdded, wrapper, time.Since(start), nil)
+	}
+	if err == nil {
+		return nil
+	}
+
+	// 逆序回滚本批次已启动的模块，保证依赖方先于被依赖方停止
+	for i := len(started) - 1; i >= 0; i-- {
+		xlog.Infof("rollback dynamic module %s", started[i].Name())
+		a.shutdownDynamicModule(started[i])
+	}
+	return err
+}
+
+// prepareDynamicModules 为一批动态模块创建运行时包装并按依赖关系排序，nil 模块会被忽略。
+func (a *App) prepareDynamicModules(mods []IModule) ([]*moduleWrapper, error) {
+	var wrappers []*moduleWrapper
+	for _, mod := range mods {
+		if mod == nil {
+			xlog.Warnln("application cannot register nil module")
+			continue
+		}
+		wrapper := a.newModuleWrapper("dynamic", mod)
+		wrappers = append(wrappers, wrapper)
+	}
+
+	wrappers, err := sortModules(wrappers, a.hasModule)
+	if err != nil {
+		xlog.Errorf("dynamic module dependency resolve failed, err %v", err)
+		return nil, err
+	}
+	return wrappers, nil
+}
+
+// 动态模块移除失败的原因，由 TryRemoveDynamicModule 返回。
+var (
+	ErrModuleNotFound = errors.New("core: dynamic module not found")                      // 模块不存在，或已被另一次移除认领
+	ErrModuleInUse    = errors.New("core: dynamic module depended on by running modules") // 仍有运行中的动态模块依赖该模块
+)
+
+// RemoveDynamicModule 同步移除并销毁指定名称的动态模块，成功时返回 true，失败原因见 TryRemoveDynamicModule。
+func (a *App) RemoveDynamicModule(name string) bool {
+	return a.TryRemoveDynamicModule(name) == nil
+}
+
+// TryRemoveDynamicModule 同步移除并销毁指定名称的动态模块，返回失败原因。
 //
 // 完整操作序列：
-//  1. cancel：向模块发送停止信号，通知 OnStart 退出主循环
+//  1. cancel：以 ErrModuleRemoved 为原因向模块发送停止信号，通知 OnStart 退出主循环
 //  2. wg.Wait：阻塞等待 OnStart goroutine 完全退出
 //  3. OnDestroy：调用销毁钩子释放模块资源
 //  4. Delete：从 dynamicModules 移除，释放引用
 //
 // 该操作是同步阻塞的，调用方会等待模块完全停止后才返回，
 // 确保模块的所有资源在函数返回前已被完整清理，避免悬挂的 goroutine 或资源泄漏。
-func (a *app) RemoveDynamicModule(name string) bool {
+//
+// 模块不存在时返回 ErrModuleNotFound；同一模块被并发移除时只有一次调用执行移除，其余调用同样返回 ErrModuleNotFound。
+// 仍有运行中的动态模块通过 IDependent 依赖该模块时拒绝移除并返回包装了 ErrModuleInUse 的错误，需先移除依赖方；
+// 已停止（如因依赖故障被 ErrParentFailed 停止）的依赖方不影响移除。
+func (a *App) TryRemoveDynamicModule(name string) error {
 	value, ok := a.dynamicModules.Load(name)
 	if !ok {
-		return false
+		return ErrModuleNotFound
 	}
 
 	wrapper, ok := value.(*moduleWrapper)
 	if !ok {
-		return false
+		return ErrModuleNotFound
 	}
 
-	wrapper.cancel()  // 发送停止信号，通知模块 OnStart 退出
-	wrapper.wg.Wait() // 等待 OnStart goroutine 完全退出后再继续
+	if dependents := a.liveDependents(name); len(dependents) > 0 {
+		xlog.Warnf("dynamic module %s cannot be removed, running modules %v depend on it", name, dependents)
+		return fmt.Errorf("%w: %s depended on by %v", ErrModuleInUse, name, dependents)
+	}
+	if !wrapper.removing.CompareAndSwap(false, true) {
+		return ErrModuleNotFound
+	}
 
-	a.destroyModule(wrapper)
+	a.shutdownDynamicModule(wrapper)
+	return nil
+}
 
-	a.dynamicModules.Delete(name)
+// liveDependents 返回直接依赖指定模块且仍在运行的动态模块名称。
+func (a *App) liveDependents(name string) (res []string) {
+	a.dynamicModules.Range(func(key, value any) bool {
+		wrapper, ok := value.(*moduleWrapper)
+		if ok && wrapper.ctx.Err() == nil && slices.Contains(moduleDependencies(wrapper), name) {
+			res = append(res, wrapper.Name())
+		}
+		return true
+	})
+	return
+}
 
-	return true
+// shutdownDynamicModule 同步停止并销毁单个动态模块，完成后将其从 dynamicModules 移除。
+func (a *App) shutdownDynamicModule(wrapper *moduleWrapper) {
+	start := time.Now()
+	wrapper.cancel(ErrModuleRemoved) // 发送停止信号，通知模块 OnStart 退出
+	a.emit(EventStopping, wrapper, 0, ErrModuleRemoved)
+	wrapper.wg.Wait() // 等待 OnStart goroutine 完全退出后再继续
+
+	err := a.destroyModule(wrapper)
+
+	if a.dynamicModules.CompareAndDelete(wrapper.Name(), wrapper) {
+		a.emit(EventDynamicRemoved, wrapper, time.Since(start), err)
+	}
 }
 
-// removeAllDynamicModules 收集所有动态模块名称后逐一移除。
+// removeAllDynamicModules 在应用关闭时收集所有动态模块后逐一关闭并移除，返回各模块的关闭结果。
 //
-// 先收集名称快照再逐一移除，而非在 Range 回调中直接移除：
+// 先收集快照再逐一移除，而非在 Range 回调中直接移除：
 // sync.Map 的文档说明 Range 期间调用 Delete 是安全的，但先收集快照能使逻辑更清晰，
-// 且避免在 Range 内部嵌套 RemoveDynamicModule（其中包含 wg.Wait）可能引发的潜在问题。
-func (a *app) removeAllDynamicModules() {
-	var moduleNames []string
+// 且避免在 Range 内部嵌套 shutdownModule（其中包含等待 goroutine 退出）可能引发的潜在问题。
+// 与 RemoveDynamicModule 不同，此处受关闭超时和全局预算约束。
+//
+// 快照按依赖关系排序后逆序关闭，保证依赖方先于被依赖方停止，与静态模块的关闭顺序一致。
+func (a *App) removeAllDynamicModules(deadline time.Time) []ShutdownResult {
+	var wrappers []*moduleWrapper
 
 	a.dynamicModules.Range(func(key, value any) bool {
-		moduleNames = append(moduleNames, key.(string))
+		if wrapper, ok := value.(*moduleWrapper); ok {
+			wrappers = append(wrappers, wrapper)
+		}
 		return true
 	})
 
-	for _, name := range moduleNames {
-		a.RemoveDynamicModule(name)
+	// 快照外的依赖（静态模块或已移除的模块）视为已满足；加载时已排除环，排序失败仅在理论上可能，此时按快照顺序关闭
+	sorted, err := sortModules(wrappers, func(string) bool { return true })
+	if err != nil {
+		xlog.Warnf("dynamic module dependency resolve failed on shutdown, err %v", err)
+	} else {
+		wrappers = sorted
+	}
+	slices.Reverse(wrappers)
+
+	results := make([]ShutdownResult, 0, len(wrappers))
+	for _, wrapper := range wrappers {
+		res := a.shutdownModule("dynamic", wrapper, deadline)
+		results = append(results, res)
+		if a.dynamicModules.CompareAndDelete(wrapper.Name(), wrapper) {
+			a.emit(EventDynamicRemoved, wrapper, res.Elapsed, res.Err)
+		}
 	}
+	return results
 }
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRemoveDynamicModuleWithLiveDependent(t *testing.T) {
+	app := NewApp()
+	base := &testModule{name: "base"}
+	child := &testModule{name: "child", deps: []string{"base"}}
+	if err := app.AddDynamicModules(child, base); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.RemoveDynamicModule("base") {
+		t.Fatal("base removed while child depends on it")
+	}
+	if _, destroyed := base.state(); destroyed {
+		t.Fatal("base destroyed while child depends on it")
+	}
+
+	if !app.RemoveDynamicModule("child") {
+		t.Fatal("remove child failed")
+	}
+	if !app.RemoveDynamicModule("base") {
+		t.Fatal("remove base failed after child removed")
+	}
+	if names := app.DynamicModules(); len(names) != 0 {
+		t.Errorf("dynamic modules = %v, want empty", names)
+	}
+}
+
+func TestRemoveAllDynamicModulesReverseOrder(t *testing.T) {
+	var log []string
+	app := NewApp()
+	// 分批加载并使名称顺序与依赖顺序相反，避免 sync.Map 的遍历顺序恰好满足依赖关系
+	mods := []*testModule{
+		{name: "z", log: &log},
+		{name: "y", deps: []string{"z"}, log: &log},
+		{name: "x", deps: []string{"y"}, log: &log},
+		{name: "w", deps: []string{"x", "z"}, log: &log},
+	}
+	for _, m := range mods {
+		if err := app.AddDynamicModules(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log = log[:0]
+	results := app.removeAllDynamicModules(time.Time{})
+
+	var order []string
+	for _, res := range results {
+		order = append(order, res.Name)
+	}
+	if want := []string{"w", "x", "y", "z"}; !slices.Equal(order, want) {
+		t.Errorf("shutdown order = %v, want %v", order, want)
+	}
+	if want := []string{"destroy w", "destroy x", "destroy y", "destroy z"}; !slices.Equal(log, want) {
+		t.Errorf("destroy order = %v, want %v", log, want)
+	}
+}
+
+func TestAddDynamicModulesAtomicRollback(t *testing.T) {
+	var log []string
+	errInit := errors.New("init failed")
+	app := NewApp()
+	existing := &testModule{name: "existing"}
+	if err := app.AddDynamicModules(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &testModule{name: "a", log: &log}
+	b := &testModule{name: "b", deps: []string{"a"}, log: &log}
+	c := &testModule{name: "c", deps: []string{"b"}, initErr: errInit, log: &log}
+	if err := app.AddDynamicModulesAtomic(c, b, a); !errors.Is(err, errInit) {
+		t.Fatalf("AddDynamicModulesAtomic err = %v, want %v", err, errInit)
+	}
+	want := []string{"init a", "init b", "init c", "destroy b", "destroy a"}
+	if !slices.Equal(log, want) {
+		t.Errorf("lifecycle order = %v, want %v", log, want)
+	}
+	if names := app.DynamicModules(); !slices.Equal(names, []string{"existing"}) {
+		t.Errorf("dynamic modules = %v, want [existing]", names)
+	}
+
+	// 与已有模块重名时不执行任何 OnInit
+	dup := &testModule{name: "existing"}
+	if err := app.AddDynamicModulesAtomic(&testModule{name: "d"}, dup); err == nil {
+		t.Error("AddDynamicModulesAtomic with duplicate name succeeded")
+	}
+	if inited, _ := dup.state(); inited {
+		t.Error("duplicate module initialized")
+	}
+	app.removeAllDynamicModules(time.Time{})
+}
+
+func TestTryRemoveDynamicModule(t *testing.T) {
+	var log []string
+	app := NewApp()
+	mod := &testModule{name: "m", log: &log}
+	if err := app.AddDynamicModules(mod, &testModule{name: "dependent", deps: []string{"m"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.TryRemoveDynamicModule("m"); !errors.Is(err, ErrModuleInUse) {
+		t.Errorf("remove depended module err = %v, want %v", err, ErrModuleInUse)
+	}
+	if err := app.TryRemoveDynamicModule("dependent"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发移除同一模块时只有一次执行销毁
+	log = log[:0]
+	errs := make(chan error, 4)
+	for range cap(errs) {
+		go func() { errs <- app.TryRemoveDynamicModule("m") }()
+	}
+	var removed int
+	for range cap(errs) {
+		switch err := <-errs; {
+		case err == nil:
+			removed++
+		case !errors.Is(err, ErrModuleNotFound):
+			t.Errorf("concurrent remove err = %v", err)
+		}
+	}
+	if removed != 1 || !slices.Equal(log, []string{"destroy m"}) {
+		t.Errorf("removed %d times, lifecycle %v, want once", removed, log)
+	}
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+const (
+	// defaultReloadTimeout 单个模块 OnReload 的最大等待时间。
+	// 重新加载通常涉及读取配置文件或 TSV 表，1 分钟足以覆盖大表加载，又能避免单个模块卡住整个重载流程。
+	defaultReloadTimeout = time.Minute
+)
+
+var (
+	// ErrReloadInProgress 表示上一次重新加载尚未结束，本次请求被忽略。
+	ErrReloadInProgress = errors.New("core: reload already in progress")
+	// ErrAppNotRunning 表示应用不处于运行状态（启动或关闭过程中），本次重新加载请求被拒绝。
+	ErrAppNotRunning = errors.New("core: application not running")
+)
+
+// IReloadable 模块重新加载接口，模块可选实现，用于在收到 SIGHUP 信号时重新加载配置。
+//
+// 若模块同时实现了 IInvoker（如内嵌 Skeleton 的模块），OnReload 总是被投递到模块自身的事件循环中执行，
+// 可无锁访问模块内部状态（如替换 tsv.Conf 实例）；事件循环未在服务（尚未启动、监督重启中或已停止）时不执行，
+// 结果为 ErrNotServing。未实现 IInvoker 的模块在框架的重新加载 goroutine 中直接调用，实现方需自行保证并发安全。
+// ctx 携带单个模块的重新加载超时时间。
+type IReloadable interface {
+	OnReload(ctx context.Context) error
+}
+
+// IInvoker 模块事件循环投递接口，用于将函数投递到模块自身的 goroutine 中串行执行。
+//
+// Skeleton 已实现此接口。Invoke 阻塞至 f 执行完毕或 ctx 结束，f 未执行前 ctx 结束时返回 ctx 的错误。
+type IInvoker interface {
+	Invoke(ctx context.Context, f func()) error
+}
+
+// ReloadResult 单个模块的重新加载结果。
+type ReloadResult struct {
+	Name    string        `json:"name"`    // 模块名称
+	Kind    string        `json:"kind"`    // 模块类型：static 或 dynamic
+	Err     error         `json:"-"`       // OnReload 返回的错误、panic 或超时，成功时为 nil；JSON 中以 error 字段输出错误文本
+	Elapsed time.Duration `json:"elapsed"` // 重新加载耗时
+	At      time.Time     `json:"at"`      // 重新加载开始时间
+}
+
+// MarshalJSON 实现 json.Marshaler 接口，Err 以错误文本输出到 error 字段，成功时省略。
+func (r ReloadResult) MarshalJSON() ([]byte, error) {
+	type plain ReloadResult
+	return json.Marshal(struct {
+		plain
+		Error string `json:"error,omitempty"`
+	}{plain(r), errorText(r.Err)})
+}
+
+// Reload 对所有实现了 IReloadable 的模块（静态模块按启动顺序在前，动态模块在后）依次执行重新加载。
+//
+// 仅在应用运行状态（AppStateRun）下执行，否则返回 ErrAppNotRunning；同一时刻只允许一次重新加载，重复调用返回 ErrReloadInProgress。
+// 单个模块失败不影响其他模块，每个模块的结果都会记录日志，并可通过 ReloadResults 查询最近一次的结果。
+func (a *App) Reload(ctx context.Context) ([]ReloadResult, error) {
+	if a.GetState() != AppStateRun {
+		return nil, ErrAppNotRunning
+	}
+	if !a.reloading.CompareAndSwap(false, true) {
+		return nil, ErrReloadInProgress
+	}
+	defer a.reloading.Store(false)
+
+	type target struct {
+		kind    string
+		wrapper *moduleWrapper
+	}
+	var targets []target
+	a.RLock()
+	for _, wrapper := range a.modules {
+		targets = append(targets, target{kind: "static", wrapper: wrapper})
+	}
+	a.RUnlock()
+	a.dynamicModules.Range(func(key, value any) bool {
+		if wrapper, ok := value.(*moduleWrapper); ok {
+			targets = append(targets, target{kind: "dynamic", wrapper: wrapper})
+		}
+		return true
+	})
+
+	var results []ReloadResult
+	for _, t := range targets {
+		reloadable, ok := t.wrapper.IModule.(IReloadable)
+		if !ok {
+			continue
+		}
+		res := reloadModule(ctx, t.kind, t.wrapper, reloadable)
+		if res.Err != nil {
+			xlog.Errorf("module %s reload failed, elapsed %s, err %v", res.Name, res.Elapsed, res.Err)
+		} else {
+			xlog.Infof("module %s reloaded, elapsed %s", res.Name, res.Elapsed)
+		}
+		results = append(results, res)
+	}
+
+	a.reloadResults.Store(&results)
+	return results, nil
+}
+
+// ReloadResults 返回最近一次重新加载的各模块结果，尚未执行过重新加载时返回 nil。
+func (a *App) ReloadResults() []ReloadResult {
+	if results := a.reloadResults.Load(); results != nil {
+		return *results
+	}
+	return nil
+}
+
+// reloadModule 在模块事件循环（实现了 IInvoker 时）或当前 goroutine 中执行单个模块的 OnReload，并捕获其中的 panic。
+func reloadModule(ctx context.Context, kind string, wrapper *moduleWrapper, reloadable IReloadable) (res ReloadResult) {
+	res = ReloadResult{
+		Name: wrapper.Name(),
+		Kind: kind,
+		At:   time.Now(),
+	}
+	defer func() {
+		res.Elapsed = time.Since(res.At)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, defaultReloadTimeout)
+	defer cancel()
+
+	// 结果经由带缓冲的 errCh 回传：Invoke 因超时提前返回时，仍在事件循环中执行的 reload 不会与本函数竞争写 res
+	errCh := make(chan error, 1)
+	reload := func() {
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				xlog.Errorf("module %s reload panic recovered, panic %v\n%s", wrapper.Name(), r, string(debug.Stack()))
+				err = fmt.Errorf("panic: %v", r)
+			}
+			errCh <- err
+		}()
+		err = reloadable.OnReload(ctx)
+	}
+
+	if invoker, ok := wrapper.IModule.(IInvoker); ok {
+		if serving, ok := wrapper.IModule.(IServing); ok && !serving.Serving() {
+			res.Err = ErrNotServing
+			return
+		}
+		if err := invoker.Invoke(ctx, reload); err != nil {
+			res.Err = err
+			return
+		}
+	} else {
+		reload()
+	}
+	res.Err = <-errCh
+	return
+}
+
+// reloadOnSignal 响应 SIGHUP 信号，在独立 goroutine 中执行重新加载，避免阻塞 Run 的信号处理循环。
+func (a *App) reloadOnSignal() {
+	go func() {
+		if _, err := a.Reload(context.Background()); err != nil {
+			xlog.Warnf("SIGHUP reload skipped, err %v", err)
+		}
+	}()
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+// reloadableModule 内嵌 Skeleton 的可重新加载模块，onReload 在 OnReload 中执行。
+type reloadableModule struct {
+	skeletonModule
+	onReload func() error
+	reloads  int // 仅在事件循环中读写
+}
+
+func (m *reloadableModule) OnReload(context.Context) error {
+	m.reloads++
+	if m.onReload != nil {
+		return m.onReload()
+	}
+	return nil
+}
+
+type blockReq struct{}
+
+// startSkeletonModules 以动态模块启动 mods，并等待其事件循环开始服务。
+func startSkeletonModules(t *testing.T, app *App, mods ...IModule) {
+	t.Helper()
+	if err := app.AddDynamicModules(mods...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { app.removeAllDynamicModules(time.Time{}) })
+	deadline := time.Now().Add(time.Second)
+	for _, m := range mods {
+		for !m.(IServing).Serving() {
+			if time.Now().After(deadline) {
+				t.Fatalf("module %s not serving", m.Name())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestReloadRunsInEventLoop(t *testing.T) {
+	app := NewApp()
+	app.setState(AppStateRun)
+	mod := &reloadableModule{skeletonModule: skeletonModule{NewSkeleton("m")}}
+	started, release := make(chan struct{}), make(chan struct{})
+	_ = mod.RegisterChanRPC(&blockReq{}, func(*chanrpc.CallInfo) *chanrpc.RetInfo {
+		close(started)
+		<-release
+		return nil
+	})
+	startSkeletonModules(t, app, mod)
+
+	// 事件循环被处理函数占用期间，OnReload 不得在其他 goroutine 中执行
+	mod.Cast("m", &blockReq{})
+	<-started
+	done := make(chan []ReloadResult, 1)
+	go func() {
+		results, _ := app.Reload(context.Background())
+		done <- results
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if _, err := app.Reload(context.Background()); !errors.Is(err, ErrReloadInProgress) {
+		t.Errorf("concurrent reload err = %v, want %v", err, ErrReloadInProgress)
+	}
+	select {
+	case <-done:
+		t.Fatal("reload finished while event loop busy")
+	default:
+	}
+	close(release)
+
+	results := <-done
+	if len(results) != 1 || results[0].Name != "m" || results[0].Kind != "dynamic" || results[0].Err != nil {
+		t.Fatalf("results = %+v, want m reloaded", results)
+	}
+	if mod.reloads != 1 {
+		t.Errorf("reloads = %d, want 1", mod.reloads)
+	}
+	if stored := app.ReloadResults(); len(stored) != 1 || stored[0].Name != "m" {
+		t.Errorf("stored results = %+v", stored)
+	}
+}
+
+func TestReloadErrors(t *testing.T) {
+	app := NewApp()
+	errBad := errors.New("bad config")
+	failing := &reloadableModule{skeletonModule: skeletonModule{NewSkeleton("failing")}, onReload: func() error { return errBad }}
+	panicking := &reloadableModule{skeletonModule: skeletonModule{NewSkeleton("panicking")}, onReload: func() error { panic("boom") }}
+	startSkeletonModules(t, app, failing, panicking)
+	// 未运行事件循环的 Skeleton 模块不在其他 goroutine 中执行 OnReload
+	idle := &reloadableModule{skeletonModule: skeletonModule{NewSkeleton("idle")}}
+	app.modules = append(app.modules, app.newModuleWrapper("static", idle))
+
+	app.setState(AppStateInit)
+	if _, err := app.Reload(context.Background()); !errors.Is(err, ErrAppNotRunning) {
+		t.Errorf("reload during init err = %v, want %v", err, ErrAppNotRunning)
+	}
+	if app.ReloadResults() != nil {
+		t.Error("rejected reload stored results")
+	}
+
+	app.setState(AppStateRun)
+	results, err := app.Reload(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := map[string]error{}
+	for _, res := range results {
+		errs[res.Name] = res.Err
+	}
+	if !errors.Is(errs["idle"], ErrNotServing) {
+		t.Errorf("idle err = %v, want %v", errs["idle"], ErrNotServing)
+	}
+	if idle.reloads != 0 {
+		t.Error("idle module reloaded outside its event loop")
+	}
+	if !errors.Is(errs["failing"], errBad) {
+		t.Errorf("failing err = %v, want %v", errs["failing"], errBad)
+	}
+	if err := errs["panicking"]; err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("panicking err = %v, want panic error", err)
+	}
+}
@@ -0,0 +1,104 @@
+package remote
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+// frameKind 帧类型。
+type frameKind uint8
+
+const (
+	kindCall  frameKind = iota + 1 // 需要回包的调用请求
+	kindCast                       // 单向投递请求，不回包
+	kindReply                      // 调用成功的响应
+	kindError                      // 调用失败的响应，text 为错误信息，payload 仍可携带 Ack
+)
+
+// headerSize 帧头长度：kind(1) + seq(8) + msgID(4) + timeout(4) + traceLen(1) + spanLen(1) + textLen(2)。
+const headerSize = 1 + 8 + 4 + 4 + 1 + 1 + 2
+
+// errShortFrame 帧长度不足以容纳帧头或声明的变长字段。
+var errShortFrame = errors.New("remote: short frame")
+
+// frame 跨进程传输的单条消息，外层由 xnet.SocketConn 负责长度分帧。
+//
+// 帧格式（大端序）：[kind 1][seq 8][msgID 4][timeout 4][traceLen 1][spanLen 1][textLen 2][trace][span][text][payload]
+//   - seq：调用序号，响应原样带回，用于匹配等待中的调用；Cast 为 0
+//   - msgID：payload 的 chanrpc 消息 ID，解码端据此创建对应类型实例；响应无 Ack 时为 0
+//   - timeout：调用方剩余的等待时间（毫秒），仅调用请求有效
+//   - trace、span：请求所属调用链的 TraceID 和发往代理模块那次调用的 SpanID，作为远端调用的父调用；
+//     调用未加入调用链或为响应时为空
+//   - text：请求为目标模块名称，错误响应为错误信息
+//   - payload：Codec 编码的请求或 Ack
+type frame struct {
+	kind    frameKind
+	seq     uint64
+	msgID   uint32
+	timeout uint32
+	span    chanrpc.SpanContext
+	text    string
+	payload []byte
+}
+
+// encode 将帧编码为字节切片，trace、span 超过 255 字节或 text 超过 65535 字节时被截断。
+func (f *frame) encode() []byte {
+	trace := truncate(f.span.TraceID, math.MaxUint8)
+	span := truncate(f.span.SpanID, math.MaxUint8)
+	text := truncate(f.text, math.MaxUint16)
+	buf := make([]byte, headerSize+len(trace)+len(span)+len(text)+len(f.payload))
+	buf[0] = byte(f.kind)
+	binary.BigEndian.PutUint64(buf[1:9], f.seq)
+	binary.BigEndian.PutUint32(buf[9:13], f.msgID)
+	binary.BigEndian.PutUint32(buf[13:17], f.timeout)
+	buf[17] = byte(len(trace))
+	buf[18] = byte(len(span))
+	binary.BigEndian.PutUint16(buf[19:21], uint16(len(text)))
+	n := headerSize
+	n += copy(buf[n:], trace)
+	n += copy(buf[n:], span)
+	n += copy(buf[n:], text)
+	copy(buf[n:], f.payload)
+	return buf
+}
+
+// truncate 将 s 截断至不超过 n 字节。
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// decodeFrame 从字节切片解码帧，payload 引用 data 的底层数组。
+func decodeFrame(data []byte) (*frame, error) {
+	if len(data) < headerSize {
+		return nil, errShortFrame
+	}
+	f := &frame{
+		kind:    frameKind(data[0]),
+		seq:     binary.BigEndian.Uint64(data[1:9]),
+		msgID:   binary.BigEndian.Uint32(data[9:13]),
+		timeout: binary.BigEndian.Uint32(data[13:17]),
+	}
+	if f.kind < kindCall || f.kind > kindError {
+		return nil, fmt.Errorf("remote: unknown frame kind %d", f.kind)
+	}
+	traceLen, spanLen := int(data[17]), int(data[18])
+	textLen := int(binary.BigEndian.Uint16(data[19:21]))
+	if len(data) < headerSize+traceLen+spanLen+textLen {
+		return nil, errShortFrame
+	}
+	n := headerSize
+	f.span.TraceID = string(data[n : n+traceLen])
+	n += traceLen
+	f.span.SpanID = string(data[n : n+spanLen])
+	n += spanLen
+	f.text = string(data[n : n+textLen])
+	f.payload = data[n+textLen:]
+	return f, nil
+}
@@ -0,0 +1,62 @@
+package remote
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	frames := []*frame{
+		{kind: kindCall, seq: 42, msgID: 7, timeout: 1500, span: chanrpc.SpanContext{TraceID: "trace", SpanID: "span"}, text: "world", payload: []byte(`{"n":1}`)},
+		{kind: kindCast, msgID: 7, text: "world"},
+		{kind: kindReply, seq: 42},
+		{kind: kindError, seq: 43, text: "boom", payload: []byte(`{}`)},
+	}
+	for _, want := range frames {
+		got, err := decodeFrame(want.encode())
+		if err != nil {
+			t.Fatalf("decode kind %d err = %v", want.kind, err)
+		}
+		if got.kind != want.kind || got.seq != want.seq || got.msgID != want.msgID || got.timeout != want.timeout ||
+			got.span != want.span || got.text != want.text || string(got.payload) != string(want.payload) {
+			t.Errorf("round trip = %+v, want %+v", got, want)
+		}
+	}
+
+	// 超长的变长字段被截断，不破坏帧结构
+	long := &frame{kind: kindCall, span: chanrpc.SpanContext{TraceID: strings.Repeat("t", 300)}, text: "world"}
+	got, err := decodeFrame(long.encode())
+	if err != nil || len(got.span.TraceID) != 255 || got.text != "world" {
+		t.Errorf("truncated frame = %+v err %v", got, err)
+	}
+}
+
+func TestDecodeFrameErrors(t *testing.T) {
+	data := (&frame{kind: kindCall, text: "world"}).encode()
+	if _, err := decodeFrame(data[:headerSize-1]); !errors.Is(err, errShortFrame) {
+		t.Errorf("short header err = %v, want %v", err, errShortFrame)
+	}
+	if _, err := decodeFrame(data[:len(data)-1]); !errors.Is(err, errShortFrame) {
+		t.Errorf("short text err = %v, want %v", err, errShortFrame)
+	}
+	data[0] = byte(kindError + 1)
+	if _, err := decodeFrame(data); err == nil {
+		t.Error("decode unknown kind succeeded")
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	if err := decodeError(chanrpc.ErrQueueFull.Error()); err != chanrpc.ErrQueueFull {
+		t.Errorf("decode = %v, want %v", err, chanrpc.ErrQueueFull)
+	}
+	err := decodeError(ErrDisconnected.Error() + ": broken pipe")
+	if !errors.Is(err, ErrDisconnected) || err.Error() != ErrDisconnected.Error()+": broken pipe" {
+		t.Errorf("decode wrapped = %v, want wrapping %v", err, ErrDisconnected)
+	}
+	if err := decodeError("boom"); err.Error() != "boom" {
+		t.Errorf("decode unknown = %v", err)
+	}
+}
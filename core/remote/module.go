@@ -0,0 +1,237 @@
+package remote
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wildmap/utility/core"
+	"github.com/wildmap/utility/core/chanrpc"
+	"github.com/wildmap/utility/xlog"
+	"github.com/wildmap/utility/xnet"
+)
+
+// Module 远程模块的本地代理，实现 core.IModule 接口。
+//
+// 代理模块内嵌 core.Skeleton，以普通模块的身份注册到 App，GetChanRPC 按名称即可解析到它，无需调用方感知远程。
+// 其 ChanRPC 服务端以默认处理函数接收任意消息，在代理模块的事件循环中编码后写入连接：
+// Cast 写入后即返回；调用通过 CallInfo.Defer 切换为延迟响应，由读取 goroutine 收到响应后完成，
+// 响应仍经调用方的 ChanAsyncRet 回到调用方模块的事件循环中执行回调，与本地模块的语义一致。
+//
+// 代理模块默认不是广播目标，需通过 WithBroadcast 声明接收的广播消息。
+//
+// 代理模块在 OnRun 中连接远端，断线后按指数退避自动重连；断线期间的调用立即以 ErrDisconnected 失败，
+// 已发出但尚未收到响应的调用同样以 ErrDisconnected 结束，不会自动重发。
+type Module struct {
+	*core.Skeleton
+	addr        string
+	remoteName  string // 远端进程中目标模块的名称
+	codec       Codec
+	callTimeout time.Duration // 调用方未设置截止时间时的超时时间
+	seq         atomic.Uint64 // 调用序号，用于匹配响应
+	link        atomic.Pointer[link]
+}
+
+// link 代理模块与远端的单条连接及其上等待响应的调用。
+type link struct {
+	sc      *xnet.SocketConn
+	pending sync.Map    // seq → *chanrpc.Reply
+	closed  atomic.Bool // 连接已断开，此后登记的调用由登记方自行以 ErrDisconnected 结束
+}
+
+// New 创建远程模块的代理，name 为本进程内的寻址名称，addr 为远端导出模块的地址。
+//
+// addr 格式与 xnet.Server 一致："10.0.0.2:7100"、"tcp://10.0.0.2:7100" 或 "unix:///var/run/app/remote.sock"。
+func New(name, addr string, opts ...Option) *Module {
+	o := newOptions(opts)
+	m := &Module{
+		Skeleton:    core.NewSkeleton(name),
+		addr:        addr,
+		remoteName:  cmp.Or(o.remoteName, name),
+		codec:       o.codec,
+		callTimeout: o.callTimeout,
+	}
+	m.ChanRPC().SetDefaultHandler(m.forward)
+	if o.broadcast {
+		m.ChanRPC().AcceptBroadcast(o.broadcasts...)
+	}
+	return m
+}
+
+// OnInit 实现 core.IModule 接口，连接在 OnRun 中建立，远端暂不可用不影响应用启动。
+func (m *Module) OnInit() error {
+	return nil
+}
+
+// OnRun 连接远端并运行事件循环，阻塞至 ctx 被取消，实现 core.IModule 接口。
+func (m *Module) OnRun(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		m.connect(ctx)
+	})
+	m.Skeleton.OnRun(ctx)
+	wg.Wait()
+}
+
+// OnDestroy 实现 core.IModule 接口，连接已在 OnRun 退出时关闭。
+func (m *Module) OnDestroy() {}
+
+// Serving 返回事件循环是否正在运行且已连接远端，实现 core.IServing 接口，断线期间健康检查判定为未就绪。
+func (m *Module) Serving() bool {
+	return m.Skeleton.Serving() && m.link.Load() != nil
+}
+
+// connect 连接远端，断线后按指数退避重连，直至 ctx 被取消。
+func (m *Module) connect(ctx context.Context) {
+	delay := minRedialDelay
+	for {
+		conn, err := dial(ctx, m.addr)
+		if err == nil {
+			delay = minRedialDelay
+			m.serve(ctx, conn)
+		} else if ctx.Err() == nil {
+			xlog.Warnf("remote module %s dial %s failed, retry in %s, err %v", m.Name(), m.addr, delay, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRedialDelay)
+	}
+}
+
+// serve 在单条连接上读取响应，直至连接断开或 ctx 被取消，退出时以 ErrDisconnected 结束全部等待中的调用。
+func (m *Module) serve(ctx context.Context, conn net.Conn) {
+	l := &link{sc: xnet.NewSocketConn(conn)}
+	m.link.Store(l)
+	xlog.Infof("remote module %s connected to %s", m.Name(), m.addr)
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = l.sc.Close()
+	})
+	done := make(chan struct{})
+	go l.sweep(done)
+	defer func() {
+		stop()
+		close(done)
+		m.link.CompareAndSwap(l, nil)
+		l.close()
+	}()
+
+	for {
+		data, err := l.sc.ReadMsg()
+		if err != nil {
+			if ctx.Err() == nil {
+				xlog.Warnf("remote module %s disconnected from %s, err %v", m.Name(), m.addr, err)
+			}
+			return
+		}
+		f, err := decodeFrame(data)
+		if err != nil {
+			xlog.Errorf("remote module %s bad frame from %s, err %v", m.Name(), m.addr, err)
+			return
+		}
+		if f.kind != kindReply && f.kind != kindError {
+			xlog.Warnf("remote module %s unexpected frame kind %d from %s", m.Name(), f.kind, m.addr)
+			continue
+		}
+		value, ok := l.pending.LoadAndDelete(f.seq)
+		if !ok {
+			// 调用已超时，响应迟到
+			continue
+		}
+
+		ack, err := unmarshalMessage(m.codec, f.msgID, f.payload)
+		if f.kind == kindError {
+			err = decodeError(f.text)
+		}
+		_ = value.(*chanrpc.Reply).Done(ack, err)
+	}
+}
+
+// forward 代理服务端的默认处理函数，在代理模块的事件循环中将请求编码后写入连接。
+func (m *Module) forward(ci *chanrpc.CallInfo) *chanrpc.RetInfo {
+	l := m.link.Load()
+	if l == nil {
+		if ci.IsCast() {
+			xlog.Warnf("remote module %s cast message_id %d dropped, err %v", m.Name(), ci.MessageID(), ErrDisconnected)
+		}
+		return &chanrpc.RetInfo{Err: ErrDisconnected}
+	}
+
+	msgID, payload, err := marshalMessage(m.codec, ci.Request)
+	if err != nil {
+		return &chanrpc.RetInfo{Err: err}
+	}
+	// 以发往代理模块的这次调用作为远端调用的父调用，使调用链跨进程延续
+	f := &frame{kind: kindCast, msgID: msgID, span: ci.Span(), text: m.remoteName, payload: payload}
+	if ci.IsCast() {
+		if err := l.sc.WriteMsg(f.encode()); err != nil {
+			xlog.Warnf("remote module %s cast message_id %d dropped, err %v", m.Name(), msgID, err)
+		}
+		return nil
+	}
+
+	timeout := m.callTimeout
+	if deadline, ok := ci.Deadline(); ok {
+		if timeout = time.Until(deadline); timeout <= 0 {
+			return &chanrpc.RetInfo{Err: context.DeadlineExceeded}
+		}
+	}
+	f.kind, f.seq = kindCall, m.seq.Add(1)
+	f.timeout = uint32(min(max(timeout.Milliseconds(), 1), math.MaxUint32))
+
+	reply := ci.Defer(timeout)
+	l.pending.Store(f.seq, reply)
+	if l.closed.Load() {
+		l.fail(f.seq, ErrDisconnected)
+		return nil
+	}
+	if err := l.sc.WriteMsg(f.encode()); err != nil {
+		l.fail(f.seq, fmt.Errorf("%w: %v", ErrDisconnected, err))
+	}
+	return nil
+}
+
+// fail 以 err 结束指定序号的等待中调用，调用已结束时不做处理。
+func (l *link) fail(seq uint64, err error) {
+	if value, ok := l.pending.LoadAndDelete(seq); ok {
+		_ = value.(*chanrpc.Reply).Done(nil, err)
+	}
+}
+
+// close 关闭连接，并以 ErrDisconnected 结束全部等待中的调用。
+func (l *link) close() {
+	l.closed.Store(true)
+	_ = l.sc.Close()
+	l.pending.Range(func(key, _ any) bool {
+		l.fail(key.(uint64), ErrDisconnected)
+		return true
+	})
+}
+
+// sweep 定期移除已超时的等待中调用，直至 done 关闭；超时调用已由 Reply 的定时器回包，迟到的响应会被直接丢弃。
+func (l *link) sweep(done <-chan struct{}) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			l.pending.Range(func(key, value any) bool {
+				if value.(*chanrpc.Reply).Completed() {
+					l.pending.Delete(key)
+				}
+				return true
+			})
+		}
+	}
+}
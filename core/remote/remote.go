@@ -0,0 +1,212 @@
+// Package remote 提供跨进程的 chanrpc 传输：将其他进程中的模块映射为本进程的普通模块，
+// 使 GetChanRPC、Cast、Call、AsyncCall 对远程模块透明可用。
+//
+// 服务进程注册导出模块 Server，将收到的请求按模块名称转发给本进程的模块：
+//
+//	core.Run(remote.NewServer("tcp://0.0.0.0:7100"), world)
+//
+// 调用进程为每个远程模块注册一个代理模块 Module，名称即为本进程内的寻址名称：
+//
+//	core.Run(remote.New("world", "tcp://10.0.0.2:7100"), gate)
+//
+// 此后 gate 中的 AsyncCall("world", req, cb) 经代理编码后发往服务进程，cb 仍在 gate 自身的事件循环中执行。
+// 请求和响应（Ack）按 chanrpc 消息 ID 还原类型：请求类型须在服务进程注册了处理函数，
+// 响应类型和本进程只发送、不处理的请求类型须通过 chanrpc.RegisterType 在解码端登记。
+//
+// 传输不做认证和加密，只应在可信网络或 Unix Socket 上使用。
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/wildmap/utility/core/chanrpc"
+	"github.com/wildmap/utility/xnet/listeners"
+)
+
+const (
+	// defaultServerName 导出模块的默认名称。
+	defaultServerName = "remote"
+	// defaultCallTimeout 调用方未设置截止时间时，远程调用的默认超时时间，与 chanrpc 延迟响应的默认超时保持一致。
+	defaultCallTimeout = 30 * time.Second
+	// minRedialDelay 代理模块断线后首次重连的延迟。
+	minRedialDelay = 100 * time.Millisecond
+	// maxRedialDelay 代理模块重连延迟的上限，远端重启期间每隔数秒探测一次，既能及时恢复又不会频繁刷日志。
+	maxRedialDelay = 5 * time.Second
+	// sweepInterval 代理模块清理已超时调用的周期，超时调用的响应不会再到达，需定期释放。
+	sweepInterval = 10 * time.Second
+)
+
+// ErrDisconnected 表示代理模块与远端的连接未建立或已断开，等待中的调用以此错误结束。
+var ErrDisconnected = errors.New("remote: disconnected")
+
+// knownErrors 跨进程传递时按错误文本还原的已知错误，使调用方仍可通过 errors.Is 判断。
+var knownErrors = []error{
+	ErrDisconnected,
+	chanrpc.ErrServerNil,
+	chanrpc.ErrServerClosed,
+	chanrpc.ErrClientClosed,
+	chanrpc.ErrInvalidMsgType,
+	chanrpc.ErrQueueFull,
+	chanrpc.ErrCallDropped,
+	chanrpc.ErrReplyTimeout,
+	chanrpc.ErrUnknownMessage,
+	chanrpc.ErrMessageIDCollision,
+	chanrpc.ErrDeadlock,
+	context.DeadlineExceeded,
+	context.Canceled,
+}
+
+// Codec 请求和响应的编解码器，可替换为 protobuf 等更紧凑的实现。
+//
+// Unmarshal 的 v 为注册类型的指针，实现须并发安全。
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// jsonCodec 基于 encoding/json 的默认编解码器。
+type jsonCodec struct{}
+
+// Marshal 实现 Codec 接口。
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal 实现 Codec 接口。
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// JSONCodec 返回基于 encoding/json 的编解码器，为默认编解码器。
+func JSONCodec() Codec {
+	return jsonCodec{}
+}
+
+// options 导出模块和代理模块的公共配置。
+type options struct {
+	name        string
+	remoteName  string
+	codec       Codec
+	callTimeout time.Duration
+	broadcast   bool  // 代理模块是否作为广播目标
+	broadcasts  []any // 代理模块接收广播的消息类型，为空表示任意类型
+}
+
+// Option 配置选项的函数类型，使用函数选项模式，导出模块和代理模块共用。
+type Option func(*options)
+
+// WithName 设置导出模块的名称，默认为 "remote"，同一进程导出多个地址时需区分名称；对代理模块无效。
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithRemoteName 设置代理模块在远端进程中对应的模块名称，默认与代理模块名称相同；对导出模块无效。
+func WithRemoteName(name string) Option {
+	return func(o *options) {
+		o.remoteName = name
+	}
+}
+
+// WithCodec 设置编解码器，默认为 JSONCodec，通信双方须使用相同的编解码器。
+func WithCodec(c Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithCallTimeout 设置调用方未设置截止时间时远程调用的超时时间，默认 30 秒；对导出模块无效。
+func WithCallTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.callTimeout = d
+	}
+}
+
+// WithBroadcast 使代理模块成为 App.Broadcast/Multicast 的投递目标，接收 messages 类型的广播并转发给远端，
+// 不传入消息时接收任意类型；对导出模块无效。
+//
+// 代理模块不注册处理函数，未设置此选项时不接收任何广播。远端未注册处理函数的广播消息会被远端丢弃。
+func WithBroadcast(messages ...any) Option {
+	return func(o *options) {
+		o.broadcast = true
+		o.broadcasts = append(o.broadcasts, messages...)
+	}
+}
+
+// newOptions 应用配置选项并填充默认值。
+func newOptions(opts []Option) *options {
+	o := &options{
+		name:        defaultServerName,
+		codec:       jsonCodec{},
+		callTimeout: defaultCallTimeout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// splitAddr 解析 xnet 风格的地址，未指定协议时默认为 tcp。
+func splitAddr(addr string) (proto, host string) {
+	proto, host, found := strings.Cut(addr, "://")
+	if !found {
+		return "tcp", addr
+	}
+	return proto, host
+}
+
+// listen 在 xnet 风格的地址上监听，支持 tcp 和 unix。
+func listen(addr string) (net.Listener, error) {
+	proto, host := splitAddr(addr)
+	return listeners.New(context.Background(), proto, host, nil)
+}
+
+// dial 连接 xnet 风格的地址，支持 tcp 和 unix。
+func dial(ctx context.Context, addr string) (net.Conn, error) {
+	proto, host := splitAddr(addr)
+	var d net.Dialer
+	return d.DialContext(ctx, proto, host)
+}
+
+// marshalMessage 编码消息，返回其 chanrpc 消息 ID 和编码结果；v 为 nil 时返回 0 和空切片。
+func marshalMessage(c Codec, v any) (uint32, []byte, error) {
+	if v == nil {
+		return 0, nil, nil
+	}
+	data, err := c.Marshal(v)
+	if err != nil {
+		return 0, nil, fmt.Errorf("remote marshal %T: %w", v, err)
+	}
+	return chanrpc.MessageID(v), data, nil
+}
+
+// unmarshalMessage 按消息 ID 还原消息，id 为 0 时返回 nil。
+func unmarshalMessage(c Codec, id uint32, data []byte) (any, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	return chanrpc.UnmarshalMessage(id, func(v any) error {
+		return c.Unmarshal(data, v)
+	})
+}
+
+// decodeError 将错误文本还原为错误，以已知错误开头的文本通过 %w 包装该错误。
+func decodeError(text string) error {
+	for _, known := range knownErrors {
+		msg := known.Error()
+		if text == msg {
+			return known
+		}
+		if rest, ok := strings.CutPrefix(text, msg+": "); ok {
+			return fmt.Errorf("%w: %s", known, rest)
+		}
+	}
+	return errors.New(text)
+}
@@ -0,0 +1,164 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wildmap/utility/core"
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+type echoReq struct{ Text string }
+
+type echoAck struct{ Text string }
+
+type failReq struct{}
+
+type notifyReq struct{ Text string }
+
+// worldModule 远端进程中被代理的目标模块。
+type worldModule struct {
+	*core.Skeleton
+	notified chan *chanrpc.CallInfo
+}
+
+func (m *worldModule) OnInit() error { return nil }
+
+func (m *worldModule) OnDestroy() {}
+
+// startRemote 在同一 App 中启动目标模块 world、监听 Unix Socket 的导出模块和名为 proxy 的代理模块，等待代理连接成功。
+func startRemote(t *testing.T) (*core.App, *worldModule) {
+	t.Helper()
+	// t.TempDir 的路径可能超过 Unix Socket 的长度限制
+	dir, err := os.MkdirTemp("", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	addr := "unix://" + filepath.Join(dir, "remote.sock")
+
+	world := &worldModule{Skeleton: core.NewSkeleton("world"), notified: make(chan *chanrpc.CallInfo, 1)}
+	_ = world.RegisterChanRPC(&echoReq{}, func(ci *chanrpc.CallInfo) *chanrpc.RetInfo {
+		return &chanrpc.RetInfo{Ack: &echoAck{Text: ci.Request.(*echoReq).Text}}
+	})
+	_ = world.RegisterChanRPC(&failReq{}, func(*chanrpc.CallInfo) *chanrpc.RetInfo {
+		return &chanrpc.RetInfo{Err: chanrpc.ErrQueueFull}
+	})
+	_ = world.RegisterChanRPC(&notifyReq{}, func(ci *chanrpc.CallInfo) *chanrpc.RetInfo {
+		world.notified <- ci
+		return nil
+	})
+	if _, err := chanrpc.RegisterType(&echoAck{}); err != nil {
+		t.Fatal(err)
+	}
+
+	app := core.NewApp()
+	proxy := New("proxy", addr, WithRemoteName("world"), WithCallTimeout(time.Second))
+	if err := app.AddDynamicModules(world, NewServer(addr), proxy); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for _, name := range []string{"proxy", "remote", "world"} {
+			app.RemoveDynamicModule(name)
+		}
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !proxy.Serving() {
+		if time.Now().After(deadline) {
+			t.Fatal("proxy not connected")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return app, world
+}
+
+func TestRemoteCallAndCast(t *testing.T) {
+	app, world := startRemote(t)
+	s := app.GetChanRPC("proxy")
+	c := chanrpc.NewClient(1)
+
+	ri := c.Call(s, &echoReq{Text: "hello"})
+	if ri.Err != nil {
+		t.Fatalf("call err = %v", ri.Err)
+	}
+	if ack, ok := ri.Ack.(*echoAck); !ok || ack.Text != "hello" {
+		t.Errorf("call ack = %#v, want echo hello", ri.Ack)
+	}
+
+	// 远端返回的已知错误在本进程中仍可通过 errors.Is 判断
+	if ri := c.Call(s, &failReq{}); !errors.Is(ri.Err, chanrpc.ErrQueueFull) {
+		t.Errorf("call err = %v, want %v", ri.Err, chanrpc.ErrQueueFull)
+	}
+
+	c.Cast(s, &notifyReq{Text: "event"})
+	select {
+	case ci := <-world.notified:
+		if req := ci.Request.(*notifyReq); req.Text != "event" || !ci.IsCast() {
+			t.Errorf("cast request = %+v cast %v, want event", req, ci.IsCast())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cast not delivered")
+	}
+}
+
+func TestRemoteCallDisconnected(t *testing.T) {
+	app := core.NewApp()
+	proxy := New("proxy", "unix:///nonexistent/remote.sock")
+	if err := app.AddDynamicModules(proxy); err != nil {
+		t.Fatal(err)
+	}
+	defer app.RemoveDynamicModule("proxy")
+
+	c := chanrpc.NewClient(1)
+	if ri := c.Call(app.GetChanRPC("proxy"), &echoReq{}); !errors.Is(ri.Err, ErrDisconnected) {
+		t.Errorf("call err = %v, want %v", ri.Err, ErrDisconnected)
+	}
+	if proxy.Serving() {
+		t.Error("disconnected proxy reported serving")
+	}
+}
+
+func TestRemoteCallDeadline(t *testing.T) {
+	app, _ := startRemote(t)
+	c := chanrpc.NewClient(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if ri := c.CallContext(ctx, app.GetChanRPC("proxy"), &echoReq{Text: "ctx"}); ri.Err != nil {
+		t.Errorf("call with deadline err = %v", ri.Err)
+	}
+}
+
+func TestRemoteTracePropagation(t *testing.T) {
+	app, world := startRemote(t)
+	c := chanrpc.NewClient(1)
+
+	parent := chanrpc.SpanContext{TraceID: "trace-1", SpanID: "span-1"}
+	c.CastContext(chanrpc.ContextWithSpan(context.Background(), parent), app.GetChanRPC("proxy"), &notifyReq{})
+	select {
+	case ci := <-world.notified:
+		// 远端处理归属同一调用链，且是代理模块那次调用的子调用
+		span := ci.Span()
+		if span.TraceID != parent.TraceID || span.SpanID == "" || span.SpanID == parent.SpanID {
+			t.Errorf("remote span = %+v, want child of %+v", span, parent)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cast not delivered")
+	}
+
+	// 未加入调用链的调用不生成追踪标识
+	c.Cast(app.GetChanRPC("proxy"), &notifyReq{})
+	select {
+	case ci := <-world.notified:
+		if span := ci.Span(); span.IsValid() {
+			t.Errorf("untraced remote span = %+v, want empty", span)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cast not delivered")
+	}
+}
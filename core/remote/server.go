@@ -0,0 +1,186 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/wildmap/utility/core"
+	"github.com/wildmap/utility/core/chanrpc"
+	"github.com/wildmap/utility/xlog"
+	"github.com/wildmap/utility/xnet"
+)
+
+// Server 导出模块，监听 xnet 地址并将远端代理模块发来的请求转发给本进程的模块，实现 core.IModule 接口。
+//
+// 每条连接由独立的 goroutine 读取和解码请求，按帧中的模块名称经 GetChanRPC 寻址：
+// 帧中携带的追踪标识经 chanrpc.ContextWithSpan 还原，Cast 以 CastContext 投递，调用以 AsyncCallContext 发起，超时时间取自调用方剩余的等待时间，
+// 响应在导出模块自身的事件循环中编码回包。导出模块内嵌 core.Skeleton，本身不注册任何消息处理函数。
+type Server struct {
+	*core.Skeleton
+	addr  string
+	codec Codec
+	ln    net.Listener
+
+	mu    sync.Mutex                    // 保护 conns
+	conns map[*xnet.SocketConn]struct{} // 当前活跃的连接，模块停止时统一关闭
+	wg    sync.WaitGroup                // 追踪监听和连接 goroutine
+}
+
+// NewServer 创建导出模块。
+//
+// addr 格式与 xnet.Server 一致：
+//   - "0.0.0.0:7100" 或 "tcp://0.0.0.0:7100"：监听 TCP 端口
+//   - "unix:///var/run/app/remote.sock"：监听 Unix Domain Socket
+func NewServer(addr string, opts ...Option) *Server {
+	o := newOptions(opts)
+	return &Server{
+		Skeleton: core.NewSkeleton(o.name),
+		addr:     addr,
+		codec:    o.codec,
+		conns:    make(map[*xnet.SocketConn]struct{}),
+	}
+}
+
+// OnInit 创建监听器，实现 core.IModule 接口。
+//
+// 在初始化阶段完成监听，端口占用等错误会使应用启动失败。
+func (s *Server) OnInit() error {
+	ln, err := listen(s.addr)
+	if err != nil {
+		return fmt.Errorf("remote listen %s failed: %w", s.addr, err)
+	}
+	s.ln = ln
+	return nil
+}
+
+// OnRun 接受连接并运行事件循环，阻塞至 ctx 被取消，随后关闭监听器和全部连接，实现 core.IModule 接口。
+func (s *Server) OnRun(ctx context.Context) {
+	s.wg.Go(s.serve)
+	s.Skeleton.OnRun(ctx)
+
+	// 事件循环退出前已等待全部进行中的调用回包，此后关闭连接不会丢失已处理完毕的响应
+	_ = s.ln.Close()
+	s.mu.Lock()
+	for sc := range s.conns {
+		_ = sc.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// OnDestroy 关闭监听器，实现 core.IModule 接口。
+//
+// 正常流程下 OnRun 已关闭监听器，此处为 OnRun 未执行（如启动失败回滚）时兜底。
+func (s *Server) OnDestroy() {
+	if s.ln != nil {
+		_ = s.ln.Close()
+	}
+}
+
+// serve 接受连接，直至监听器关闭。
+func (s *Server) serve() {
+	xlog.Infof("remote server %s listening at %s", s.Name(), s.ln.Addr())
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				xlog.Errorf("remote server %s accept failed, err %v", s.Name(), err)
+			}
+			return
+		}
+		sc := xnet.NewSocketConn(conn)
+		s.mu.Lock()
+		s.conns[sc] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Go(func() {
+			s.serveConn(sc)
+		})
+	}
+}
+
+// serveConn 读取并分发单条连接上的请求，直至连接断开。
+func (s *Server) serveConn(sc *xnet.SocketConn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, sc)
+		s.mu.Unlock()
+		_ = sc.Close()
+	}()
+
+	xlog.Infof("remote server %s accepted %s", s.Name(), sc.RemoteAddr())
+	for {
+		data, err := sc.ReadMsg()
+		if err != nil {
+			xlog.Infof("remote server %s connection %s closed, err %v", s.Name(), sc.RemoteAddr(), err)
+			return
+		}
+		f, err := decodeFrame(data)
+		if err != nil {
+			xlog.Errorf("remote server %s connection %s bad frame, err %v", s.Name(), sc.RemoteAddr(), err)
+			return
+		}
+		s.dispatch(sc, f)
+	}
+}
+
+// dispatch 解码请求并投递给目标模块，调用的响应经 reply 写回连接。
+func (s *Server) dispatch(sc *xnet.SocketConn, f *frame) {
+	if f.kind != kindCall && f.kind != kindCast {
+		xlog.Warnf("remote server %s unexpected frame kind %d from %s", s.Name(), f.kind, sc.RemoteAddr())
+		return
+	}
+
+	req, err := unmarshalMessage(s.codec, f.msgID, f.payload)
+	if err == nil && req == nil {
+		err = chanrpc.ErrInvalidMsgType
+	}
+	if err != nil {
+		if f.kind == kindCall {
+			s.reply(sc, f.seq, nil, err)
+		} else {
+			xlog.Warnf("remote server %s cast to %s dropped, err %v", s.Name(), f.text, err)
+		}
+		return
+	}
+
+	// 还原调用方的调用链，本进程中的处理及其嵌套调用均归属同一条调用链
+	ctx := context.Background()
+	if f.span.IsValid() {
+		ctx = chanrpc.ContextWithSpan(ctx, f.span)
+	}
+
+	if f.kind == kindCast {
+		s.CastContext(ctx, f.text, req)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(f.timeout)*time.Millisecond)
+	err = s.AsyncCallContext(ctx, f.text, req, func(ri *chanrpc.RetInfo) {
+		cancel()
+		s.reply(sc, f.seq, ri.Ack, ri.Err)
+	})
+	if err != nil {
+		cancel()
+		s.reply(sc, f.seq, nil, err)
+	}
+}
+
+// reply 编码响应并写回连接，连接已断开时响应被丢弃，调用方以 ErrDisconnected 结束。
+func (s *Server) reply(sc *xnet.SocketConn, seq uint64, ack any, err error) {
+	msgID, payload, merr := marshalMessage(s.codec, ack)
+	if merr != nil {
+		msgID, payload, err = 0, nil, merr
+	}
+
+	f := &frame{kind: kindReply, seq: seq, msgID: msgID, payload: payload}
+	if err != nil {
+		f.kind, f.text = kindError, err.Error()
+	}
+	if werr := sc.WriteMsg(f.encode()); werr != nil {
+		xlog.Debugf("remote server %s reply to %s dropped, err %v", s.Name(), sc.RemoteAddr(), werr)
+	}
+}
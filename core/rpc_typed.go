@@ -0,0 +1,24 @@
+package core
+
+import (
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+// RegisterTyped 在模块骨架上以类型化处理函数注册消息，见 chanrpc.RegisterTyped。
+//
+// Go 不支持泛型方法，因此以包级函数的形式提供，通常在 OnInit 中调用：
+//
+//	core.RegisterTyped(m.Skeleton, func(req *LoginReq) (*LoginAck, error) { ... })
+func RegisterTyped[Req, Resp any](s *Skeleton, f func(req Req) (Resp, error)) error {
+	return chanrpc.RegisterTyped(s.server, f)
+}
+
+// CallTyped 向指定模块发起同步调用并将响应转换为 Resp 类型，语义与 Skeleton.Call 相同。
+func CallTyped[Resp any](s *Skeleton, mod string, req any) (Resp, error) {
+	return chanrpc.CallTyped[Resp](s.client, s.App().GetChanRPC(mod), req)
+}
+
+// AsyncCallTyped 向指定模块发起异步调用，回调在本模块事件循环中收到已转换为 Resp 类型的响应，语义与 Skeleton.AsyncCall 相同。
+func AsyncCallTyped[Resp any](s *Skeleton, mod string, req any, cb func(resp Resp, err error)) error {
+	return chanrpc.AsyncCallTyped(s.client, s.App().GetChanRPC(mod), req, cb)
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+const (
+	// defaultShutdownTimeout 单个模块优雅关闭的默认最大等待时间。
+	// 设置为 30 分钟是为了兼容可能持有长时间锁或大批量数据落盘的模块，
+	// 模块可通过实现 IShutdownTimeout 单独调整，超时后的处理方式由 ShutdownPolicy 决定。
+	defaultShutdownTimeout = 30 * time.Minute
+	// minShutdownGrace 全局关闭预算耗尽后，每个剩余模块仍可获得的最短等待时间。
+	// 预算耗尽并不意味着剩余模块无法退出，给予短暂的宽限使及时响应停止信号的模块仍能正常执行 OnDestroy，
+	// 代价是关闭流程最多超出预算 剩余模块数 × minShutdownGrace。
+	minShutdownGrace = 100 * time.Millisecond
+)
+
+// IShutdownTimeout 模块关闭超时接口，模块可选实现，用于声明自身优雅关闭的最大等待时间。
+//
+// 未实现此接口或返回值不大于 0 的模块使用默认的 30 分钟。
+// 若通过 SetShutdownBudget 设置了全局关闭预算，实际等待时间不超过剩余预算。
+type IShutdownTimeout interface {
+	ShutdownTimeout() time.Duration
+}
+
+// ShutdownPolicy 模块关闭超时后的处理策略。
+type ShutdownPolicy int32
+
+const (
+	// ShutdownSkipDestroy 超时后跳过该模块的 OnDestroy 并继续关闭其他模块（默认策略）。
+	// 模块 goroutine 可能仍在运行，此时调用 OnDestroy 会与其并发访问资源，跳过销毁可避免数据竞争和损坏。
+	ShutdownSkipDestroy ShutdownPolicy = iota
+	// ShutdownWait 超时后记录错误并继续等待模块 goroutine 退出，退出后正常调用 OnDestroy。
+	// 该策略保证资源按序释放，但模块无法退出时关闭流程会一直阻塞，全局预算对其不生效。
+	ShutdownWait
+	// ShutdownExit 超时后立即以退出码 1 终止进程，由外部进程管理器负责重启。
+	ShutdownExit
+)
+
+// ShutdownOutcome 单个模块的关闭结果。
+type ShutdownOutcome int32
+
+const (
+	ShutdownCompleted       ShutdownOutcome = iota // 模块在时限内退出并完成销毁
+	ShutdownTimeoutWaited                          // 模块关闭超时，按 ShutdownWait 策略等待其退出后完成销毁
+	ShutdownTimeoutSkipped                         // 模块关闭超时，按 ShutdownSkipDestroy 策略跳过了 OnDestroy
+	ShutdownDestroyPanicked                        // 模块已退出，但 OnDestroy 发生 panic
+)
+
+// String 返回关闭结果的可读名称，用于日志输出。
+func (o ShutdownOutcome) String() string {
+	switch o {
+	case ShutdownCompleted:
+		return "completed"
+	case ShutdownTimeoutWaited:
+		return "timeout_waited"
+	case ShutdownTimeoutSkipped:
+		return "timeout_skipped"
+	case ShutdownDestroyPanicked:
+		return "destroy_panicked"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(o))
+	}
+}
+
+// ShutdownResult 单个模块的关闭结果报告，由 Run 在应用关闭完成后返回给调用方。
+type ShutdownResult struct {
+	Name    string          `json:"name"`    // 模块名称
+	Kind    string          `json:"kind"`    // 模块类型：static 或 dynamic
+	Outcome ShutdownOutcome `json:"outcome"` // 关闭结果
+	Elapsed time.Duration   `json:"elapsed"` // 从发出停止信号到关闭流程结束的耗时
+	Err     error           `json:"-"`       // 超时或销毁 panic 等异常信息，正常关闭时为 nil；JSON 中以 error 字段输出错误文本
+}
+
+// MarshalJSON 实现 json.Marshaler 接口，error 接口值无法直接序列化，Err 以错误文本输出到 error 字段，正常关闭时省略。
+func (r ShutdownResult) MarshalJSON() ([]byte, error) {
+	type plain ShutdownResult
+	return json.Marshal(struct {
+		plain
+		Error string `json:"error,omitempty"`
+	}{plain(r), errorText(r.Err)})
+}
+
+// errorText 返回 err 的文本，err 为 nil 时返回空字符串。
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// SetShutdownBudget 设置整个应用关闭流程的总时间预算，须在 Run 之前调用，d 不大于 0 表示不限制。
+//
+// 设置后每个模块的实际等待时间为 min(模块超时时间, max(剩余预算, 100ms))：
+// 预算耗尽后剩余模块各自仅有 100ms 的宽限时间，及时退出的模块仍正常销毁，未退出的按超时处理，
+// 关闭流程最多超出预算 剩余模块数 × 100ms。ShutdownWait 策略下超时后继续无限等待，预算对其不生效。
+func (a *App) SetShutdownBudget(d time.Duration) {
+	a.shutdownBudget.Store(int64(max(d, 0)))
+}
+
+// SetShutdownPolicy 设置模块关闭超时后的处理策略，须在 Run 之前调用。
+func (a *App) SetShutdownPolicy(p ShutdownPolicy) {
+	a.shutdownPolicy.Store(int32(p))
+}
+
+// shutdownDeadline 根据全局关闭预算计算关闭流程的截止时间，未设置预算时返回零值。
+func (a *App) shutdownDeadline() time.Time {
+	budget := time.Duration(a.shutdownBudget.Load())
+	if budget <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(budget)
+}
+
+// moduleShutdownTimeout 计算模块本次关闭的等待时间：取模块声明的超时时间与剩余预算中的较小值，
+// 剩余预算不足 minShutdownGrace 时按 minShutdownGrace 计算。
+func moduleShutdownTimeout(wrapper *moduleWrapper, deadline time.Time) time.Duration {
+	timeout := defaultShutdownTimeout
+	if st, ok := wrapper.IModule.(IShutdownTimeout); ok && st.ShutdownTimeout() > 0 {
+		timeout = st.ShutdownTimeout()
+	}
+	if !deadline.IsZero() {
+		timeout = min(timeout, max(time.Until(deadline), minShutdownGrace))
+	}
+	return timeout
+}
+
+// shutdownModule 优雅关闭单个模块，完整流程为：发送停止信号 → 等待 goroutine 退出（含超时保护）→ 调用 OnDestroy。
+//
+// 超时保护通过独立 goroutine + done channel 实现，而非直接阻塞，
+// 原因是 wg.Wait 本身不支持超时，需要借助 select 和 timer 组合。
+// 超时后的处理由 ShutdownPolicy 决定，默认跳过 OnDestroy，避免与仍在运行的模块 goroutine 并发释放资源。
+func (a *App) shutdownModule(kind string, wrapper *moduleWrapper, deadline time.Time) (res ShutdownResult) {
+	res = ShutdownResult{
+		Name: wrapper.Name(),
+		Kind: kind,
+	}
+	start := time.Now()
+	defer func() {
+		res.Elapsed = time.Since(start)
+		xlog.Infof("module %s shutdown %s, elapsed %s", wrapper.Name(), res.Outcome, res.Elapsed)
+	}()
+
+	xlog.Infof("signaling module %s shutdown", wrapper.Name())
+	wrapper.cancel(ErrAppShutdown) // 通过 context 取消向模块的 OnStart 发送停止信号
+	a.emit(EventStopping, wrapper, 0, ErrAppShutdown)
+
+	// 在辅助 goroutine 中等待模块退出，配合 select + timer 实现超时保护
+	done := make(chan struct{})
+	go func() {
+		wrapper.wg.Wait()
+		close(done)
+	}()
+
+	timeout := moduleShutdownTimeout(wrapper, deadline)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		xlog.Infof("module %s goroutine exited", wrapper.Name())
+	case <-timer.C:
+		res.Err = fmt.Errorf("module %s shutdown timeout after %s", wrapper.Name(), timeout)
+		xlog.Errorf("module %s shutdown timeout after %s", wrapper.Name(), timeout)
+
+		switch ShutdownPolicy(a.shutdownPolicy.Load()) {
+		case ShutdownWait:
+			res.Outcome = ShutdownTimeoutWaited
+			<-done
+			xlog.Infof("module %s goroutine exited after timeout", wrapper.Name())
+		case ShutdownExit:
+			xlog.Errorf("module %s shutdown timeout, exiting process", wrapper.Name())
+			xlog.CloseLogger()
+			os.Exit(1)
+		default:
+			res.Outcome = ShutdownTimeoutSkipped
+			xlog.Errorf("module %s destroy skipped because its goroutine is still running", wrapper.Name())
+			return
+		}
+	}
+
+	xlog.Infof("destroying module %s", wrapper.Name())
+	if err := a.destroyModule(wrapper); err != nil {
+		res.Outcome = ShutdownDestroyPanicked
+		res.Err = err
+	}
+	return
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownResultJSON(t *testing.T) {
+	res := ShutdownResult{
+		Name:    "m",
+		Kind:    "static",
+		Outcome: ShutdownTimeoutSkipped,
+		Elapsed: time.Second,
+		Err:     errors.New("module m shutdown timeout after 1s"),
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"m","kind":"static","outcome":2,"elapsed":1000000000,"error":"module m shutdown timeout after 1s"}`
+	if string(data) != want {
+		t.Errorf("marshal = %s, want %s", data, want)
+	}
+
+	res.Err = nil
+	if data, _ = json.Marshal(res); strings.Contains(string(data), "error") {
+		t.Errorf("marshal = %s, want error omitted", data)
+	}
+}
+
+// slowModule 收到停止信号后直至 release 关闭才退出 OnRun 的模块。
+type slowModule struct {
+	testModule
+	timeout time.Duration
+	release chan struct{}
+}
+
+func newSlowModule(name string, timeout time.Duration) *slowModule {
+	m := &slowModule{testModule: testModule{name: name}, timeout: timeout, release: make(chan struct{})}
+	m.run = func(ctx context.Context) {
+		<-ctx.Done()
+		<-m.release
+	}
+	return m
+}
+
+func (m *slowModule) ShutdownTimeout() time.Duration { return m.timeout }
+
+// runWrapper 以静态模块的方式启动 mod 的 OnRun goroutine。
+func runWrapper(app *App, mod IModule) *moduleWrapper {
+	wrapper := app.newModuleWrapper("static", mod)
+	wrapper.wg.Add(1)
+	go app.onRunModule(wrapper, false)
+	return wrapper
+}
+
+func TestShutdownSkipDestroy(t *testing.T) {
+	app := NewApp()
+	mod := newSlowModule("slow", 20*time.Millisecond)
+	defer close(mod.release)
+
+	res := app.shutdownModule("static", runWrapper(app, mod), time.Time{})
+	if res.Outcome != ShutdownTimeoutSkipped || res.Err == nil {
+		t.Errorf("outcome %s err %v, want timeout_skipped", res.Outcome, res.Err)
+	}
+	if _, destroyed := mod.state(); destroyed {
+		t.Error("running module destroyed")
+	}
+}
+
+func TestShutdownWait(t *testing.T) {
+	app := NewApp()
+	app.SetShutdownPolicy(ShutdownWait)
+	mod := newSlowModule("slow", 20*time.Millisecond)
+	time.AfterFunc(60*time.Millisecond, func() { close(mod.release) })
+
+	res := app.shutdownModule("static", runWrapper(app, mod), time.Now())
+	if res.Outcome != ShutdownTimeoutWaited || res.Err == nil {
+		t.Errorf("outcome %s err %v, want timeout_waited", res.Outcome, res.Err)
+	}
+	if _, destroyed := mod.state(); !destroyed {
+		t.Error("module not destroyed after exit")
+	}
+	if res.Elapsed < 60*time.Millisecond {
+		t.Errorf("elapsed %s, want wait for module exit", res.Elapsed)
+	}
+}
+
+func TestShutdownExit(t *testing.T) {
+	if os.Getenv("CORE_TEST_SHUTDOWN_EXIT") == "1" {
+		app := NewApp()
+		app.SetShutdownPolicy(ShutdownExit)
+		app.shutdownModule("static", runWrapper(app, newSlowModule("slow", 10*time.Millisecond)), time.Time{})
+		return
+	}
+
+	// ShutdownExit 会终止进程，在子进程中执行
+	cmd := exec.Command(os.Args[0], "-test.run=^TestShutdownExit$")
+	cmd.Env = append(os.Environ(), "CORE_TEST_SHUTDOWN_EXIT=1")
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Errorf("child process err = %v, want exit code 1", err)
+	}
+}
+
+func TestShutdownBudgetExhausted(t *testing.T) {
+	app := NewApp()
+	app.SetShutdownBudget(30 * time.Millisecond)
+	quick := &testModule{name: "quick"}
+	slow := newSlowModule("slow", 0)
+	defer close(slow.release)
+	// 逆序关闭：slow 先关闭并耗尽预算
+	app.modules = []*moduleWrapper{runWrapper(app, quick), runWrapper(app, slow)}
+	app.setState(AppStateRun)
+
+	start := time.Now()
+	results := app.stop()
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want 2", results)
+	}
+	if res := results[0]; res.Name != "slow" || res.Outcome != ShutdownTimeoutSkipped {
+		t.Errorf("slow result = %+v, want timeout_skipped", res)
+	}
+	// 预算耗尽后剩余模块仍有短暂宽限，及时退出的模块正常销毁
+	if res := results[1]; res.Name != "quick" || res.Outcome != ShutdownCompleted {
+		t.Errorf("quick result = %+v, want completed", res)
+	}
+	if _, destroyed := quick.state(); !destroyed {
+		t.Error("quick module not destroyed after budget exhausted")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown took %s, want bounded by budget", elapsed)
+	}
+}
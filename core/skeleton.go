@@ -2,6 +2,10 @@ package core
 
 import (
 	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 
 	"github.com/wildmap/utility/core/chanrpc"
 	"github.com/wildmap/utility/core/timermgr"
@@ -18,7 +22,7 @@ type IRPC interface {
 	// Cast 单向消息投递，不等待结果，适合日志上报、事件通知等不需要响应的场景。
 	Cast(mod string, req any)
 	// Call 同步 RPC 调用，阻塞等待对端处理完成并返回结果。
-	// 警告：若调用链形成环（A→B→A），将导致死锁，生产环境应优先使用 AsyncCall。
+	// 若调用链将形成环（A→B→A），调用立即失败并返回 chanrpc.ErrDeadlock（含调用链），生产环境仍应优先使用 AsyncCall。
 	Call(mod string, req any) *chanrpc.RetInfo
 	// AsyncCall 异步 RPC 调用，立即返回，结果通过 cb 回调在调用方 goroutine 处理。
 	// 回调在事件循环中串行执行，可安全访问模块内部状态，无需加锁。
@@ -54,6 +58,16 @@ type Skeleton struct {
 	timer  *timermgr.TimerMgr // 定时器管理器，负责创建、调度和取消定时任务
 	server *chanrpc.Server    // ChanRPC 服务端，接收并路由来自其他模块的 RPC 调用
 	client *chanrpc.Client    // ChanRPC 客户端，向其他模块发起 RPC 调用
+	invoke chan func()        // 投递到事件循环执行的函数队列，由 Invoke 写入
+
+	app       *App        // 所属应用实例，用于跨模块 RPC 寻址，注册到 App 时自动绑定，未绑定时使用默认实例
+	serving   atomic.Bool // 事件循环是否正在处理消息，实现 IServing 供健康检查判定就绪性
+	timerOnce sync.Once   // 保证定时器分发器只启动一次，OnRun 被监督策略重新运行时不重复启动
+}
+
+// appBinder 模块绑定所属 App 的内部接口，App 注册模块时对实现了该接口的模块自动调用。
+type appBinder interface {
+	BindApp(a *App)
 }
 
 // NewSkeleton 创建模块骨架，初始化 ChanRPC 和定时器组件。
@@ -66,7 +80,10 @@ func NewSkeleton(name string) *Skeleton {
 		server: chanrpc.NewServer(10000),
 		client: chanrpc.NewClient(10000),
 		timer:  timermgr.NewTimerMgr(10000),
+		invoke: make(chan func(), 16),
 	}
+	s.server.SetName(name)
+	s.client.SetOwner(s.server) // 同步调用以本模块 Server 标识等待方，用于死锁检测
 	return s
 }
 
@@ -80,29 +97,119 @@ func (s *Skeleton) Name() string {
 // 事件循环采用 select 多路复用以下三类事件，保证在单一 goroutine 内串行处理：
 //  1. ctx.Done()：接收框架的停止信号，触发模块关闭流程
 //  2. ChanAsyncRet：处理本模块发起的异步 RPC 调用的返回结果（执行注册的 Callback）
-//  3. ChanCall：处理其他模块发来的 RPC 调用请求（查找并执行已注册的 Handler）
+//  3. 调用通道：处理其他模块发来的 RPC 调用请求（查找并执行已注册的 Handler），按高、普通、低优先级出队
 //  4. ChanTimer：处理到期的定时器事件（执行注册的 TimerHandler，并自动续期 Ticker）
+//  5. invoke：执行通过 Invoke 投递的函数（如框架的重新加载请求）
 //
 // 单 goroutine 串行处理是性能与正确性权衡的结果：
 // 牺牲了 CPU 并行利用率，换取了零锁开销和极低的编程复杂度。
+//
+// 有调用积压时通过 Server.Poll 按优先级逐条处理（低优先级有防饿死保护），每处理一条后非阻塞地检查一次其他事件，
+// 保证调用洪峰期间停止信号、异步回调和定时器仍能及时处理；无积压时阻塞等待任一事件。
+//
+// OnRun 可重入：动态模块 panic 后被监督策略重启时会再次调用，定时器和 ChanRPC 状态保持不变。
 func (s *Skeleton) OnRun(ctx context.Context) {
-	s.timer.Run()
+	s.timerOnce.Do(s.timer.Run)
+	s.serving.Store(true)
+	defer s.serving.Store(false)
+	high, low := s.server.Lane(chanrpc.PriorityHigh), s.server.Lane(chanrpc.PriorityLow)
 	for {
+		if ci := s.server.Poll(); ci != nil {
+			s.server.Exec(ci)
+			select {
+			case <-ctx.Done():
+				s.stop()
+				return
+			case ri := <-s.client.ChanAsyncRet:
+				s.client.AsyncCallback(ri)
+			case t := <-s.timer.ChanTimer():
+				t.Cb()
+			case f := <-s.invoke:
+				s.execInvoke(f)
+			default:
+			}
+			continue
+		}
+
 		select {
 		case <-ctx.Done():
-			s.close()
-			xlog.Infof("%s stopped", s.name)
+			s.stop()
 			return
 		case ri := <-s.client.ChanAsyncRet:
 			s.client.AsyncCallback(ri)
+		case ci := <-high:
+			s.server.Exec(ci)
 		case ci := <-s.server.ChanCall:
 			s.server.Exec(ci)
+		case ci := <-low:
+			s.server.Exec(ci)
 		case t := <-s.timer.ChanTimer():
 			t.Cb()
+		case f := <-s.invoke:
+			s.execInvoke(f)
 		}
 	}
 }
 
+// stop 响应停止信号：标记停止服务并清理资源。
+func (s *Skeleton) stop() {
+	s.serving.Store(false)
+	s.close()
+	xlog.Infof("%s stopped", s.name)
+}
+
+// ErrNotServing 表示模块事件循环未在运行，无法向其投递函数。
+var ErrNotServing = errors.New("core: skeleton is not serving")
+
+// Invoke 将 f 投递到模块事件循环中执行，阻塞至 f 执行完毕或 ctx 结束，实现 IInvoker 接口。
+//
+// f 与 RPC Handler、定时器回调串行执行，可无锁访问模块内部状态；f 中的 panic 会被捕获并记录日志。
+// 不得在模块自身的事件循环中调用 Invoke，否则会因等待自身而阻塞至 ctx 结束。
+func (s *Skeleton) Invoke(ctx context.Context, f func()) error {
+	if !s.serving.Load() {
+		return ErrNotServing
+	}
+
+	done := make(chan struct{})
+	select {
+	case s.invoke <- func() {
+		defer close(done)
+		f()
+	}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// execInvoke 执行一个投递到事件循环的函数，捕获其中的 panic，防止影响模块主循环。
+func (s *Skeleton) execInvoke(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			xlog.Errorf("%s invoke panic recovered, panic %v\n%s", s.name, r, string(debug.Stack()))
+		}
+	}()
+	f()
+}
+
+// Serving 返回事件循环是否正在处理消息，实现 IServing 接口。
+func (s *Skeleton) Serving() bool {
+	return s.serving.Load()
+}
+
+// ReportMetrics 填充 ChanRPC 客户端和定时器指标，实现 IMetricsReporter 接口。
+func (s *Skeleton) ReportMetrics(m *ModuleMetrics) {
+	cm := s.client.Metrics()
+	m.Client = &cm
+	m.ActiveTimers = s.timer.ActiveCount()
+}
+
 // close 在模块退出前有序清理资源：停止定时器 → 关闭 RPC 服务端 → 等待异步调用完成。
 //
 // 轮询等待异步回调（!Idle）：直到所有发出的异步调用都收到响应并执行完回调，
@@ -151,6 +258,22 @@ func (s *Skeleton) CancelTimer(id int64) {
 	s.timer.CancelTimer(id)
 }
 
+// BindApp 将骨架绑定到指定 App，此后 Cast/Call/AsyncCall 在该 App 的模块注册表中寻址。
+//
+// 模块注册到 App（Register/Run/AddDynamicModules）时会自动绑定，通常无需手动调用；
+// 仅在注册前就需要发起 RPC 调用等特殊场景下显式调用。
+func (s *Skeleton) BindApp(a *App) {
+	s.app = a
+}
+
+// App 返回骨架所属的 App，未绑定时返回全局默认实例。
+func (s *Skeleton) App() *App {
+	if s.app == nil {
+		return defaultApp
+	}
+	return s.app
+}
+
 // ChanRPC 返回模块的 ChanRPC 服务端，供框架注册到模块映射表，以及外部模块通过 GetChanRPC 获取后投递消息。
 func (s *Skeleton) ChanRPC() *chanrpc.Server {
 	return s.server
@@ -163,27 +286,83 @@ func (s *Skeleton) RegisterChanRPC(msg any, f chanrpc.Handler) error {
 	return s.server.Register(msg, f)
 }
 
+// RegisterChanRPCWithPriority 注册 RPC 消息处理函数，并指定该消息类型进入的优先级通道。
+//
+// 如将运维指令注册为 chanrpc.PriorityHigh，使其越过积压的普通消息优先处理；
+// 单次调用可通过 chanrpc.WithPriority 覆盖注册时的优先级。
+func (s *Skeleton) RegisterChanRPCWithPriority(msg any, f chanrpc.Handler, p chanrpc.Priority) error {
+	return s.server.RegisterWithPriority(msg, f, p)
+}
+
+// RegisterChanRPCBatch 注册 RPC 消息的批量处理函数，同类型的排队调用累积后一次交给 f 处理，maxBatch 不大于 0 表示不限制批次大小。
+func (s *Skeleton) RegisterChanRPCBatch(msg any, f chanrpc.BatchHandler, maxBatch int) error {
+	return s.server.RegisterBatch(msg, f, maxBatch)
+}
+
+// CoalesceChanRPC 为已注册的幂等查询消息开启请求合并，相同的在途请求共享一次处理，须在 OnInit 中调用。
+func (s *Skeleton) CoalesceChanRPC(msg any, key chanrpc.KeyFunc) error {
+	return s.server.EnableCoalescing(msg, key)
+}
+
+// AddServerInterceptor 为本模块的 ChanRPC 服务端追加拦截器，对所有注册的 Handler 生效，须在 OnInit 中调用。
+func (s *Skeleton) AddServerInterceptor(interceptors ...chanrpc.ServerInterceptor) {
+	s.server.AddInterceptor(interceptors...)
+}
+
+// AddClientInterceptor 为本模块发出的 Cast/Call/AsyncCall 追加拦截器，须在 OnInit 中调用。
+func (s *Skeleton) AddClientInterceptor(interceptors ...chanrpc.ClientInterceptor) {
+	s.client.AddInterceptor(interceptors...)
+}
+
+// SetBackpressure 设置本模块调用通道已满时的处理策略（拒绝、限时阻塞、丢弃最旧或溢出队列），通常在 OnInit 中调用。
+func (s *Skeleton) SetBackpressure(bp chanrpc.Backpressure) {
+	s.server.SetBackpressure(bp)
+}
+
 // AsyncCall 向指定模块发起异步 RPC 调用，结果通过 cb 回调在本模块事件循环中执行。
 //
 // 回调在 OnStart 的 select 循环中消费 ChanAsyncRet 时执行，
 // 与模块其他事件处理串行，无并发问题，可安全访问模块内部状态。
 func (s *Skeleton) AsyncCall(mod string, req any, cb chanrpc.Callback) error {
-	server := defaultApp.GetChanRPC(mod)
+	server := s.App().GetChanRPC(mod)
 	return s.client.AsyncCall(server, req, cb)
 }
 
+// AsyncCallContext 向指定模块发起异步 RPC 调用，ctx 的截止时间约束整个往返过程。
+//
+// ctx 在响应到达前结束时，cb 以 ctx 的错误在本模块事件循环中执行，之后到达的响应被丢弃。
+func (s *Skeleton) AsyncCallContext(ctx context.Context, mod string, req any, cb chanrpc.Callback) error {
+	server := s.App().GetChanRPC(mod)
+	return s.client.AsyncCallContext(ctx, server, req, cb)
+}
+
 // Cast 向指定模块投递单向消息，不等待响应，适合日志记录、事件通知等无需确认的场景。
 func (s *Skeleton) Cast(mod string, req any) {
-	server := defaultApp.GetChanRPC(mod)
+	server := s.App().GetChanRPC(mod)
 	s.client.Cast(server, req)
 }
 
+// CastContext 向指定模块投递单向消息，ctx 携带的追踪标识和优先级随消息传递，消息出队前 ctx 已结束时不再处理。
+func (s *Skeleton) CastContext(ctx context.Context, mod string, req any) {
+	server := s.App().GetChanRPC(mod)
+	s.client.CastContext(ctx, server, req)
+}
+
 // Call 向指定模块发起同步 RPC 调用，阻塞当前模块的事件处理直到收到响应。
 //
 // 危险提示：Call 会阻塞本模块对其他消息的处理；
-// 若 A 调用 B，同时 B 也在等待 A 的响应，则形成死锁，需通过仔细的调用关系分析来规避。
+// 若 A 调用 B，同时 B 也在等待 A 的响应，则形成死锁。框架在阻塞前检测循环等待，
+// 命中时返回 chanrpc.ErrDeadlock 及完整调用链，但检测只能避免死锁，无法挽回失败的调用，
 // 在事件循环中应优先使用 AsyncCall，仅在调用关系明确单向且不存在环路时才使用 Call。
 func (s *Skeleton) Call(mod string, req any) *chanrpc.RetInfo {
-	server := defaultApp.GetChanRPC(mod)
+	server := s.App().GetChanRPC(mod)
 	return s.client.Call(server, req)
 }
+
+// CallContext 向指定模块发起同步 RPC 调用，阻塞至收到响应或 ctx 结束。
+//
+// 与 Call 相同存在循环等待的风险，但 ctx 的截止时间保证调用最终返回，不会永久阻塞事件循环。
+func (s *Skeleton) CallContext(ctx context.Context, mod string, req any) *chanrpc.RetInfo {
+	server := s.App().GetChanRPC(mod)
+	return s.client.CallContext(ctx, server, req)
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wildmap/utility/core/chanrpc"
+)
+
+type whichAppReq struct{}
+
+type appEvent struct{}
+
+func TestSkeletonAddressesOwnApp(t *testing.T) {
+	type instance struct {
+		app    *App
+		gate   *skeletonModule
+		events chan string
+	}
+	var instances []instance
+	for _, tag := range []string{"first", "second"} {
+		app := NewApp()
+		events := make(chan string, 1)
+		world := &skeletonModule{NewSkeleton("world")}
+		_ = world.RegisterChanRPC(&whichAppReq{}, func(*chanrpc.CallInfo) *chanrpc.RetInfo {
+			return &chanrpc.RetInfo{Ack: tag}
+		})
+		_ = world.RegisterChanRPC(&appEvent{}, func(*chanrpc.CallInfo) *chanrpc.RetInfo {
+			events <- tag
+			return nil
+		})
+		gate := &skeletonModule{NewSkeleton("gate")}
+		startSkeletonModules(t, app, world, gate)
+		instances = append(instances, instance{app: app, gate: gate, events: events})
+	}
+
+	for i, in := range instances {
+		want := []string{"first", "second"}[i]
+		if in.gate.App() != in.app {
+			t.Errorf("gate %d bound to another app", i)
+		}
+		if ri := in.gate.Call("world", &whichAppReq{}); ri.Err != nil || ri.Ack != want {
+			t.Errorf("gate %d call = %v %v, want %s", i, ri.Ack, ri.Err, want)
+		}
+		in.gate.Cast("world", &appEvent{})
+		select {
+		case got := <-in.events:
+			if got != want {
+				t.Errorf("gate %d cast delivered to %s", i, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("gate %d cast not delivered", i)
+		}
+	}
+	for i, in := range instances {
+		select {
+		case got := <-in.events:
+			t.Errorf("app %d received extra event from %s", i, got)
+		default:
+		}
+	}
+}
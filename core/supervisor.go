@@ -0,0 +1,203 @@
+package core
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/wildmap/utility/xlog"
+)
+
+const (
+	// defaultMinBackoff RestartBackoff 策略首次重启的默认延迟。
+	defaultMinBackoff = 100 * time.Millisecond
+	// defaultMaxBackoff RestartBackoff 策略重启延迟的默认上限。
+	// 30 秒足以让依赖的外部服务（数据库、AMQP 等）完成故障恢复，又不至于让模块长时间不可用。
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// RestartPolicy 动态模块 OnRun 发生 panic 后的重启策略。
+type RestartPolicy int32
+
+const (
+	// RestartNever 不重启（默认策略），模块进入 dead 状态，但仍保留在动态模块集合中，需手动 RemoveDynamicModule。
+	RestartNever RestartPolicy = iota
+	// RestartAlways 立即重启，适合偶发 panic 且可快速恢复的模块，建议配合 MaxRestarts 防止持续崩溃时空转。
+	RestartAlways
+	// RestartBackoff 按指数退避延迟重启，延迟从 MinBackoff 开始逐次翻倍，不超过 MaxBackoff。
+	RestartBackoff
+)
+
+// String 返回重启策略的可读名称，用于日志输出。
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartAlways:
+		return "always"
+	case RestartBackoff:
+		return "backoff"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(p))
+	}
+}
+
+// SupervisorSpec 动态模块的监督配置。
+//
+// MaxRestarts 对 RestartAlways 和 RestartBackoff 均生效：Window 时间窗口内的重启次数达到上限后不再重启，
+// 模块进入 dead 状态。Window 不大于 0 时统计模块生命周期内的全部重启次数。
+// RestartBackoff 的退避次数同样只统计 Window 内的重启，模块稳定运行超过 Window 后延迟自动回落到 MinBackoff；
+// Window 不大于 0 时退避次数统计连续的重启，模块重启后稳定运行超过 MaxBackoff 即回落到 MinBackoff。
+type SupervisorSpec struct {
+	Policy      RestartPolicy           // 重启策略
+	MinBackoff  time.Duration           // RestartBackoff 首次重启延迟，不大于 0 时使用默认的 100ms
+	MaxBackoff  time.Duration           // RestartBackoff 最大重启延迟，不大于 0 时使用默认的 30s
+	MaxRestarts int                     // Window 内允许的最大重启次数，不大于 0 表示不限制
+	Window      time.Duration           // 重启次数的统计窗口
+	Notify      func(e SupervisorEvent) // 模块崩溃后的通知回调，在模块 goroutine 中同步调用，不应阻塞
+}
+
+// ISupervised 动态模块监督接口，模块可选实现，用于声明 OnRun 发生 panic 后的重启策略。
+//
+// 仅对动态模块生效，静态模块 panic 后进程仍以退出码 255 终止。
+// 未实现此接口的动态模块等同于 RestartNever。
+// 重启时框架在同一 goroutine 中再次调用 OnRun，每轮传入从模块 ctx 新派生的子 ctx，
+// 上一轮的 ctx 在重启前以 ErrSupervisorRestart 取消，使其派生的 goroutine 随之退出。
+// 模块需保证 OnRun 可重入（Skeleton 已满足）。
+type ISupervised interface {
+	SupervisorSpec() SupervisorSpec
+}
+
+// SupervisorEvent 模块崩溃后的监督事件，通过 SupervisorSpec.Notify 通知调用方。
+type SupervisorEvent struct {
+	Name     string        // 模块名称
+	Panic    any           // OnRun 中 recover 得到的 panic 值
+	Restarts int64         // 本次之前已累计的重启次数
+	Restart  bool          // 是否将重启模块，false 表示模块进入 dead 状态
+	Delay    time.Duration // 距离重启的延迟，仅 Restart 为 true 时有效
+}
+
+// moduleStatus 模块 OnRun goroutine 的运行状态，用于 Stats、Metrics 和健康检查展示。
+type moduleStatus int32
+
+const (
+	moduleStatusIdle       moduleStatus = iota // 尚未启动
+	moduleStatusRunning                        // OnRun 正在运行
+	moduleStatusRestarting                     // OnRun 发生 panic，正在等待监督策略重启
+	moduleStatusDead                           // OnRun 发生 panic 且不再重启，模块仍保留在注册表中
+	moduleStatusStopped                        // OnRun 已正常退出
+)
+
+// String 返回模块状态的可读名称。
+func (s moduleStatus) String() string {
+	switch s {
+	case moduleStatusIdle:
+		return "idle"
+	case moduleStatusRunning:
+		return "running"
+	case moduleStatusRestarting:
+		return "restarting"
+	case moduleStatusDead:
+		return "dead"
+	case moduleStatusStopped:
+		return "stopped"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(s))
+	}
+}
+
+// superviseRestart 在动态模块 OnRun 发生 panic 后按其监督策略决定是否重启，返回重启延迟。
+//
+// 仅在模块自身 goroutine 中调用，wrapper 的重启记录因此无需加锁。
+// Window 不大于 0 时不保留重启时间，MaxRestarts 按累计重启次数判断，避免重启记录随模块生命周期无限增长。
+func superviseRestart(wrapper *moduleWrapper, panicked any) (time.Duration, bool) {
+	sup, ok := wrapper.IModule.(ISupervised)
+	if !ok {
+		return 0, false
+	}
+	spec := sup.SupervisorSpec()
+
+	event := SupervisorEvent{
+		Name:     wrapper.Name(),
+		Panic:    panicked,
+		Restarts: wrapper.restarts.Load(),
+	}
+	defer notifySupervisor(spec, &event)
+
+	if spec.Policy != RestartAlways && spec.Policy != RestartBackoff {
+		return 0, false
+	}
+
+	now := time.Now()
+	var restarts int // 统计窗口内（Window 不大于 0 时为生命周期内）的重启次数
+	if spec.Window > 0 {
+		// 丢弃窗口外的重启记录，restartLog 按时间递增，找到第一个窗口内的记录即可
+		i := 0
+		for i < len(wrapper.restartLog) && now.Sub(wrapper.restartLog[i]) > spec.Window {
+			i++
+		}
+		wrapper.restartLog = wrapper.restartLog[i:]
+		restarts = len(wrapper.restartLog)
+	} else {
+		restarts = int(wrapper.restarts.Load())
+	}
+	if spec.MaxRestarts > 0 && restarts >= spec.MaxRestarts {
+		xlog.Errorf("module %s restarted %d times within %s, giving up", wrapper.Name(), restarts, spec.Window)
+		return 0, false
+	}
+
+	if spec.Policy == RestartBackoff {
+		n := restarts
+		if spec.Window <= 0 {
+			// 上一次重启后稳定运行超过 maxBackoff 时重新从 minBackoff 开始退避
+			if _, maxBackoff := backoffBounds(spec); !wrapper.restartAt.IsZero() && now.Sub(wrapper.restartAt) >= maxBackoff {
+				wrapper.restartStreak = 0
+			}
+			n = wrapper.restartStreak
+		}
+		event.Delay = restartBackoff(spec, n)
+	}
+	if spec.Window > 0 {
+		wrapper.restartLog = append(wrapper.restartLog, now)
+	} else {
+		wrapper.restartStreak++
+		wrapper.restartAt = now.Add(event.Delay)
+	}
+	event.Restart = true
+	return event.Delay, true
+}
+
+// backoffBounds 返回 spec 的退避延迟上下限，未设置时使用默认值。
+func backoffBounds(spec SupervisorSpec) (minBackoff, maxBackoff time.Duration) {
+	minBackoff, maxBackoff = spec.MinBackoff, spec.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	return
+}
+
+// restartBackoff 计算第 n 次（从 0 开始）重启的指数退避延迟。
+func restartBackoff(spec SupervisorSpec, n int) time.Duration {
+	minBackoff, maxBackoff := backoffBounds(spec)
+	delay := minBackoff
+	for ; n > 0 && delay < maxBackoff; n-- {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// notifySupervisor 调用监督事件回调，并捕获回调中的 panic，避免影响模块 goroutine 的重启流程。
+func notifySupervisor(spec SupervisorSpec, event *SupervisorEvent) {
+	if spec.Notify == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			xlog.Errorf("module %s supervisor notify panic recovered, panic %v\n%s", event.Name, r, string(debug.Stack()))
+		}
+	}()
+	spec.Notify(*event)
+}
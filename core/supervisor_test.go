@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	spec := SupervisorSpec{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	for _, tc := range []struct {
+		n    int
+		want time.Duration
+	}{
+		{0, 10 * time.Millisecond},
+		{1, 20 * time.Millisecond},
+		{2, 40 * time.Millisecond},
+		{3, 50 * time.Millisecond},
+		{64, 50 * time.Millisecond},
+	} {
+		if got := restartBackoff(spec, tc.n); got != tc.want {
+			t.Errorf("restartBackoff(%d) = %s, want %s", tc.n, got, tc.want)
+		}
+	}
+	if got := restartBackoff(SupervisorSpec{}, 0); got != defaultMinBackoff {
+		t.Errorf("default first backoff = %s, want %s", got, defaultMinBackoff)
+	}
+	if got := restartBackoff(SupervisorSpec{}, 64); got != defaultMaxBackoff {
+		t.Errorf("default max backoff = %s, want %s", got, defaultMaxBackoff)
+	}
+}
+
+// supervisedModule OnRun 总是 panic 的受监督测试模块。
+type supervisedModule struct {
+	testModule
+	spec SupervisorSpec
+	runs atomic.Int32
+}
+
+func (m *supervisedModule) SupervisorSpec() SupervisorSpec { return m.spec }
+
+func (m *supervisedModule) OnRun(context.Context) {
+	m.runs.Add(1)
+	panic("crash")
+}
+
+func TestSupervisorRestartAndGiveUp(t *testing.T) {
+	events := make(chan SupervisorEvent, 8)
+	crash := &supervisedModule{
+		testModule: testModule{name: "crash"},
+		spec: SupervisorSpec{
+			Policy:      RestartBackoff,
+			MinBackoff:  time.Millisecond,
+			MaxBackoff:  time.Second,
+			MaxRestarts: 2,
+			Notify:      func(e SupervisorEvent) { events <- e },
+		},
+	}
+	stopped := make(chan error, 1)
+	child := &testModule{name: "child", deps: []string{"crash"}, run: func(ctx context.Context) {
+		<-ctx.Done()
+		stopped <- StopCause(ctx)
+	}}
+
+	app := NewApp()
+	if err := app.AddDynamicModules(crash, child); err != nil {
+		t.Fatal(err)
+	}
+	defer app.removeAllDynamicModules(time.Time{})
+
+	wantDelays := []time.Duration{time.Millisecond, 2 * time.Millisecond}
+	for i := 0; i < 3; i++ {
+		select {
+		case e := <-events:
+			if e.Restarts != int64(i) {
+				t.Errorf("event %d restarts = %d, want %d", i, e.Restarts, i)
+			}
+			if i < len(wantDelays) {
+				if !e.Restart || e.Delay != wantDelays[i] {
+					t.Errorf("event %d = restart %v delay %s, want restart after %s", i, e.Restart, e.Delay, wantDelays[i])
+				}
+			} else if e.Restart {
+				t.Errorf("event %d restart = true, want give up after MaxRestarts", i)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("supervisor event %d not received", i)
+		}
+	}
+
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, ErrParentFailed) {
+			t.Errorf("child stop cause = %v, want %v", err, ErrParentFailed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dependent module not stopped after its dependency died")
+	}
+	if runs := crash.runs.Load(); runs != 3 {
+		t.Errorf("OnRun ran %d times, want 3", runs)
+	}
+
+	value, _ := app.dynamicModules.Load("crash")
+	wrapper := value.(*moduleWrapper)
+	deadline := time.Now().Add(time.Second)
+	for moduleStatus(wrapper.status.Load()) != moduleStatusDead {
+		if time.Now().After(deadline) {
+			t.Fatalf("status = %s, want dead", moduleStatus(wrapper.status.Load()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSuperviseRestartWithoutWindow(t *testing.T) {
+	app := NewApp()
+	mod := &supervisedModule{
+		testModule: testModule{name: "crash"},
+		spec:       SupervisorSpec{Policy: RestartBackoff, MinBackoff: time.Millisecond, MaxBackoff: 20 * time.Millisecond, MaxRestarts: 100},
+	}
+	wrapper := app.newModuleWrapper("dynamic", mod)
+
+	// restart 模拟 onRunModule：延迟结束后累计重启次数
+	restart := func() time.Duration {
+		delay, ok := superviseRestart(wrapper, "crash")
+		if !ok {
+			t.Fatal("module not restarted")
+		}
+		wrapper.restarts.Add(1)
+		return delay
+	}
+	for i, want := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond} {
+		if got := restart(); got != want {
+			t.Errorf("restart %d delay = %s, want %s", i, got, want)
+		}
+	}
+	if len(wrapper.restartLog) != 0 {
+		t.Errorf("restart log = %d entries, want none without window", len(wrapper.restartLog))
+	}
+
+	// 稳定运行超过 MaxBackoff 后退避回落
+	wrapper.restartAt = time.Now().Add(-mod.spec.MaxBackoff)
+	if got := restart(); got != time.Millisecond {
+		t.Errorf("delay after stable run = %s, want %s", got, time.Millisecond)
+	}
+
+	// 无统计窗口时 MaxRestarts 按累计重启次数判断
+	wrapper.restarts.Store(int64(mod.spec.MaxRestarts))
+	if _, ok := superviseRestart(wrapper, "crash"); ok {
+		t.Error("module restarted beyond MaxRestarts")
+	}
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"runtime"
+	"runtime/pprof"
+)
+
+// IThreadLocked 模块线程绑定接口，模块可选实现，用于声明 OnRun goroutine 是否需要独占系统线程。
+//
+// LockOSThread 返回 true 时，框架在运行 OnRun 前调用 runtime.LockOSThread 将 goroutine 绑定到专用系统线程，
+// 适用于依赖线程本地状态的库（如 OpenGL、部分 CGO 库）。未实现此接口或返回 false 的模块不绑定线程，
+// 由 Go 调度器自由调度，大量动态模块（如每个战斗实例一个模块）场景下可显著减少系统线程数。
+type IThreadLocked interface {
+	LockOSThread() bool
+}
+
+// ThreadUsage 应用的系统线程使用情况。
+type ThreadUsage struct {
+	Locked  int64 `json:"locked"`  // 当前被模块 goroutine 独占的系统线程数
+	Created int   `json:"created"` // 进程启动以来累计创建的系统线程数，取自 "threadcreate" profile 的计数，不是当前存活的线程数
+}
+
+// Threads 返回当前的系统线程使用情况。
+func (a *App) Threads() ThreadUsage {
+	return ThreadUsage{
+		Locked:  a.lockedThreads.Load(),
+		Created: pprof.Lookup("threadcreate").Count(),
+	}
+}
+
+// lockModuleThread 按模块的 IThreadLocked 声明绑定系统线程，返回是否已绑定，须在模块 goroutine 中调用。
+func (a *App) lockModuleThread(wrapper *moduleWrapper) bool {
+	locker, ok := wrapper.IModule.(IThreadLocked)
+	if !ok || !locker.LockOSThread() {
+		return false
+	}
+	runtime.LockOSThread()
+	wrapper.lockedThread.Store(true)
+	a.lockedThreads.Add(1)
+	return true
+}
+
+// unlockModuleThread 解除 lockModuleThread 建立的线程绑定。
+func (a *App) unlockModuleThread(wrapper *moduleWrapper) {
+	a.lockedThreads.Add(-1)
+	wrapper.lockedThread.Store(false)
+	runtime.UnlockOSThread()
+}
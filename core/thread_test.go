@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// threadModule 声明是否独占系统线程的测试模块。
+type threadModule struct {
+	testModule
+	lock bool
+}
+
+func (m *threadModule) LockOSThread() bool { return m.lock }
+
+func TestThreadLockedModules(t *testing.T) {
+	app := NewApp()
+	plain := &testModule{name: "plain"}
+	optOut := &threadModule{testModule: testModule{name: "opt-out"}}
+	locked := &threadModule{testModule: testModule{name: "locked"}, lock: true}
+	if err := app.AddDynamicModules(plain, optOut, locked); err != nil {
+		t.Fatal(err)
+	}
+	defer app.removeAllDynamicModules(time.Time{})
+
+	deadline := time.Now().Add(time.Second)
+	for app.Threads().Locked != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("locked threads = %d, want 1", app.Threads().Locked)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for _, m := range app.Metrics() {
+		if want := m.Name == "locked"; m.LockedThread != want {
+			t.Errorf("module %s locked thread = %v, want %v", m.Name, m.LockedThread, want)
+		}
+	}
+
+	if !app.RemoveDynamicModule("locked") {
+		t.Fatal("remove locked module failed")
+	}
+	if got := app.Threads().Locked; got != 0 {
+		t.Errorf("locked threads after removal = %d, want 0", got)
+	}
+}
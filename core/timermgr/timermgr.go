@@ -2,6 +2,7 @@ package timermgr
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/wildmap/utility/xlog"
 	"github.com/wildmap/utility/xtime"
@@ -88,6 +89,7 @@ type TimerMgr struct {
 	timers     map[int64]*Timer        // timerID → 定时器业务元数据
 	handlers   map[string]TimerHandler // kind → 处理函数，注册后不再修改
 	dispatcher *Dispatcher             // 底层多级时间轮分发器，在独立 goroutine 中运行
+	active     atomic.Int64            // timers 中的定时器数量，供其他 goroutine 无锁读取监控指标
 }
 
 // NewTimerMgr 创建定时器管理器，参数 l 为底层分发器通道的缓冲容量。
@@ -134,6 +136,12 @@ func (tm *TimerMgr) getTimer(timerID int64) *Timer {
 
 func (tm *TimerMgr) setTimer(timerID int64, timer *Timer) {
 	tm.timers[timerID] = timer
+	tm.active.Store(int64(len(tm.timers)))
+}
+
+// ActiveCount 返回当前活跃（已创建且未触发/未取消）的定时器数量，可在任意 goroutine 中安全调用。
+func (tm *TimerMgr) ActiveCount() int64 {
+	return tm.active.Load()
 }
 
 // GetTimerByKind 通过 kind 查询第一个匹配的定时器元数据。
@@ -312,4 +320,5 @@ func (tm *TimerMgr) CancelTimer(id int64) {
 	}
 	tm.dispatcher.CancelTimer(id)
 	delete(tm.timers, id) // 同步清理业务层元数据，防止 timers map 无限增长
+	tm.active.Store(int64(len(tm.timers)))
 }
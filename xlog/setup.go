@@ -111,6 +111,11 @@ func SetLevel(l zapcore.Level) {
 	levelController.SetLevel(l)
 }
 
+// GetLevel 获取当前全局日志输出级别，通常与 SetLevel 配合用于运维接口展示和调整日志级别。
+func GetLevel() zapcore.Level {
+	return levelController.Level()
+}
+
 // fileWriter 创建基于 timberjack 的按天轮转日志文件写入器。
 //
 // 轮转策略：